@@ -0,0 +1,115 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const defaultAnchorStyle = "github"
+
+// anchorStyle is how a markdown renderer creates the link paths of headers.
+type anchorStyle struct {
+	// formatPath formats a header body as a relative link path starting with `#`.
+	formatPath func(headerBody string) string
+
+	// duplicateSep is put between a duplicate header's link path and its number.
+	duplicateSep string
+}
+
+var anchorStyles = map[string]anchorStyle{
+	"github":    {formatHeaderPath, "-"},
+	"gitlab":    {formatGitLabHeaderPath, "-"},
+	"bitbucket": {formatBitbucketHeaderPath, "_"},
+	"azure":     {formatAzureHeaderPath, "-"},
+	"mkdocs":    {formatMkDocsHeaderPath, "_"},
+}
+
+var nonWordPattern = regexp.MustCompile(`[^\p{L}\p{N}_\- ]`)
+var hyphensPattern = regexp.MustCompile(`-{2,}`)
+var asciiNonWordPattern = regexp.MustCompile(`[^\w\s-]`)
+var hyphensOrSpacesPattern = regexp.MustCompile(`[-\s]+`)
+
+// getAnchorStyle returns the anchor style with the given name. If the name is empty, the
+// default style is returned.
+func getAnchorStyle(name string) (anchorStyle, error) {
+	if len(name) == 0 {
+		name = defaultAnchorStyle
+	}
+	style, ok := anchorStyles[strings.ToLower(name)]
+	if !ok {
+		return anchorStyle{}, fmt.Errorf(
+			"unknown anchor style %q. The anchor styles are: %s",
+			name, strings.Join(anchorStyleNames(), ", "),
+		)
+	}
+	return style, nil
+}
+
+// anchorStyleNames returns the names of all the anchor styles in alphabetical order.
+func anchorStyleNames() []string {
+	names := make([]string, 0, len(anchorStyles))
+	for name := range anchorStyles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// formatGitLabHeaderPath formats a markdown header body as a relative link path
+// compatible with GitLab's markdown rendering. Letters are lowercased, characters other
+// than letters, digits, spaces, dashes, and underscores are removed, spaces are
+// replaced with dashes, and consecutive dashes are replaced with one dash.
+func formatGitLabHeaderPath(headerBody string) string {
+	headerBody = nonWordPattern.ReplaceAllString(strings.ToLower(strings.TrimSpace(headerBody)), "")
+	headerBody = hyphensPattern.ReplaceAllString(strings.ReplaceAll(headerBody, " ", "-"), "-")
+	return "#" + headerBody
+}
+
+// formatBitbucketHeaderPath formats a markdown header body as a relative link path
+// compatible with Bitbucket's markdown rendering. It is like GitLab's link path with
+// "markdown-header-" at the start.
+func formatBitbucketHeaderPath(headerBody string) string {
+	return "#markdown-header-" + strings.TrimPrefix(formatGitLabHeaderPath(headerBody), "#")
+}
+
+// formatAzureHeaderPath formats a markdown header body as a relative link path
+// compatible with Azure DevOps wiki rendering. Letters are lowercased, spaces are
+// replaced with dashes, and special characters are percent-encoded.
+func formatAzureHeaderPath(headerBody string) string {
+	headerBody = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(headerBody)), " ", "-")
+	return "#" + url.PathEscape(headerBody)
+}
+
+// formatMkDocsHeaderPath formats a markdown header body as a relative link path
+// compatible with MkDocs' default rendering. Non-ASCII characters and special
+// characters except dashes and underscores are removed, letters are lowercased, and
+// each run of spaces and dashes is replaced with one dash.
+func formatMkDocsHeaderPath(headerBody string) string {
+	headerBody = strings.Map(func(ch rune) rune {
+		if ch > unicode.MaxASCII {
+			return -1
+		}
+		return ch
+	}, headerBody)
+	headerBody = asciiNonWordPattern.ReplaceAllString(headerBody, "")
+	headerBody = strings.ToLower(strings.TrimSpace(headerBody))
+	return "#" + hyphensOrSpacesPattern.ReplaceAllString(headerBody, "-")
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAnchorStyles(t *testing.T) {
+	tests := []struct {
+		style, input, want string
+	}{
+		{"github", "Get  all (v2) accounts", "#get--all-v2-accounts"},
+		{"gitlab", "Get  all (v2) accounts", "#get-all-v2-accounts"},
+		{"gitlab", "课客 果国", "#课客-果国"},
+		{"bitbucket", "Get all (v2) accounts", "#markdown-header-get-all-v2-accounts"},
+		{"azure", "Get all (v2) accounts", "#get-all-%28v2%29-accounts"},
+		{"mkdocs", "Get  all (v2) accounts", "#get-all-v2-accounts"},
+		{"mkdocs", "café menu", "#caf-menu"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %q", test.style, test.input), func(t *testing.T) {
+			style, err := getAnchorStyle(test.style)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ans := style.formatPath(test.input); ans != test.want {
+				t.Errorf("%s formatPath(%q) = %q, want %q", test.style, test.input, ans, test.want)
+			}
+		})
+	}
+}
+
+func TestHeaderLinkerDuplicates(t *testing.T) {
+	tests := []struct {
+		style string
+		want  []string
+	}{
+		{"github", []string{"#a-b", "#a-b-1", "#a-b-2"}},
+		{"bitbucket", []string{"#markdown-header-a-b", "#markdown-header-a-b_1", "#markdown-header-a-b_2"}},
+		{"mkdocs", []string{"#a-b", "#a-b_1", "#a-b_2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.style, func(t *testing.T) {
+			style, err := getAnchorStyle(test.style)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			headerLinks := newHeaderLinker(style)
+			for _, want := range test.want {
+				if ans := headerLinks.headerPath("a b"); ans != want {
+					t.Errorf("headerPath(\"a b\") = %q, want %q", ans, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAnchorStyleDefault(t *testing.T) {
+	style, err := getAnchorStyle("")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ans := style.formatPath("A b"); ans != "#a-b" {
+		t.Errorf("default formatPath(\"A b\") = %q, want \"#a-b\"", ans)
+	}
+}
+
+func TestGetAnchorStyleUnknown(t *testing.T) {
+	if _, err := getAnchorStyle("word"); err == nil {
+		t.Error("getAnchorStyle(\"word\") returned nil error, want non-nil error")
+	}
+}
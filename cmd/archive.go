@@ -0,0 +1,255 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+var zipMagic = []byte("PK\x03\x04")
+
+// isCollectionPath reports whether a path has the file extension of a file that can hold
+// collections.
+func isCollectionPath(path string) bool {
+	path = strings.ToLower(path)
+	for _, ext := range []string{".json", ".json.gz", ".zip"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// readCollections reads all the collections in a file, or in stdin if the path is "-".
+// The file can be a collection, a data dump from Postman's "Export data" with many
+// collections, or a gzip file or zip archive of either. Other files in a zip archive,
+// such as environments, are ignored. Compression is detected from the file's content,
+// not its name.
+func readCollections(path string) ([]map[string]any, error) {
+	input, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+	r := bufio.NewReader(input)
+	magic, _ := r.Peek(len(zipMagic))
+
+	if bytes.HasPrefix(magic, gzipMagic) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return decodeCollections(gz)
+	}
+	if bytes.HasPrefix(magic, zipMagic) {
+		var archive *zip.Reader
+		if file, ok := input.(*os.File); ok {
+			info, err := file.Stat()
+			if err != nil {
+				return nil, err
+			}
+			archive, err = zip.NewReader(file, info.Size())
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			// A zip archive's index is at its end, so the archive must be read first.
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			archive, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return readZipCollections(archive)
+	}
+	return decodeCollections(r)
+}
+
+// decodeCollections converts a collection or a Postman data dump from a stream of JSON
+// to the collections in it.
+func decodeCollections(r io.Reader) ([]map[string]any, error) {
+	var value map[string]any
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: unexpected data after the collection")
+	}
+	return collectionsIn(value)
+}
+
+// collectionsIn returns the collections in a decoded collection or Postman data dump.
+// Collections in the Collection v1 format, which data dumps use, are converted to the
+// Collection v2.1 format.
+func collectionsIn(value map[string]any) ([]map[string]any, error) {
+	dumpCollections, ok := value["collections"].([]any)
+	if !ok {
+		if isV1Collection(value) {
+			value = convertV1Collection(value)
+		}
+		if err := checkCollection(value); err != nil {
+			return nil, err
+		}
+		return []map[string]any{value}, nil
+	}
+
+	collections := make([]map[string]any, 0, len(dumpCollections))
+	for _, collectionAny := range dumpCollections {
+		collection, ok := collectionAny.(map[string]any)
+		if !ok {
+			continue
+		}
+		if isV1Collection(collection) {
+			collection = convertV1Collection(collection)
+		}
+		if err := checkCollection(collection); err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	return collections, nil
+}
+
+// readZipCollections reads the collections in each JSON file in a zip archive. JSON files
+// that aren't collections or data dumps are ignored.
+func readZipCollections(archive *zip.Reader) ([]map[string]any, error) {
+	var collections []map[string]any
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !strings.HasSuffix(strings.ToLower(file.Name), ".json") {
+			continue
+		}
+		value, err := decodeZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name, err)
+		}
+		_, isCollection := value["info"]
+		_, isDump := value["collections"]
+		if !isCollection && !isDump {
+			continue
+		}
+		found, err := collectionsIn(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name, err)
+		}
+		collections = append(collections, found...)
+	}
+	if len(collections) == 0 {
+		return nil, errors.New("no collections found in the zip archive")
+	}
+	return collections, nil
+}
+
+func decodeZipFile(file *zip.File) (map[string]any, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var value map[string]any
+	if err := json.NewDecoder(r).Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// collectionName returns a collection's name.
+func collectionName(collection map[string]any) string {
+	info, _ := collection["info"].(map[string]any)
+	name, _ := info["name"].(string)
+	return name
+}
+
+// collectionID returns a collection's Postman ID, or an empty string if it has none.
+func collectionID(collection map[string]any) string {
+	info, _ := collection["info"].(map[string]any)
+	id, _ := info["_postman_id"].(string)
+	return id
+}
+
+// selectCollections chooses collections by name or ID. If no names or IDs are given,
+// there must be exactly one collection to choose.
+func selectCollections(collections []map[string]any, namesOrIDs []string) ([]map[string]any, error) {
+	if len(namesOrIDs) == 0 {
+		switch len(collections) {
+		case 0:
+			return nil, errors.New("no collections found")
+		case 1:
+			return collections, nil
+		}
+		names := make([]string, len(collections))
+		for i, collection := range collections {
+			names[i] = fmt.Sprintf("%q", collectionName(collection))
+		}
+		return nil, fmt.Errorf(
+			"%d collections found: %s. Choose one or more by name or ID with --collection",
+			len(collections), strings.Join(names, ", "),
+		)
+	}
+
+	var selected []map[string]any
+	for _, nameOrID := range namesOrIDs {
+		var matches []map[string]any
+		for _, collection := range collections {
+			if collectionName(collection) == nameOrID || collectionID(collection) == nameOrID {
+				matches = append(matches, collection)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("no collection found with the name or ID %q", nameOrID)
+		case 1:
+			selected = append(selected, matches[0])
+		default:
+			return nil, fmt.Errorf("%d collections are named %q. Choose one by ID instead", len(matches), nameOrID)
+		}
+	}
+	return selected, nil
+}
+
+// readSelectedCollection reads the chosen collections from a file, or from stdin if the
+// path is "-". If more than one collection is chosen, they are merged into one collection
+// with the given title. See readCollections and selectCollections.
+func readSelectedCollection(path string, namesOrIDs []string, title string) (map[string]any, error) {
+	collections, err := readCollections(path)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selectCollections(collections, namesOrIDs)
+	if err != nil {
+		if path == "-" {
+			return nil, fmt.Errorf("stdin: %s", err)
+		}
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if len(selected) == 1 {
+		return selected[0], nil
+	}
+	return mergeCollections(selected, make([]string, len(selected)), title), nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testArchiveCollection returns a small collection with the given ID and name.
+func testArchiveCollection(id, name string) map[string]any {
+	return map[string]any{
+		"info": map[string]any{"_postman_id": id, "name": name, "schema": collectionSchema},
+		"item": []any{
+			map[string]any{
+				"name":    "list " + name,
+				"request": map[string]any{"method": "GET", "url": map[string]any{"raw": "/" + name}},
+			},
+		},
+	}
+}
+
+func writeTestJSON(t *testing.T, path string, value any) {
+	t.Helper()
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, jsonBytes, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestZip saves a zip archive with files of the given JSON values.
+func writeTestZip(t *testing.T, path string, files map[string]any) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	for name, value := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.NewEncoder(w).Encode(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func collectionNames(collections []map[string]any) []string {
+	names := make([]string, len(collections))
+	for i, collection := range collections {
+		names[i] = collectionName(collection)
+	}
+	return names
+}
+
+func TestReadCollections(t *testing.T) {
+	dir := t.TempDir()
+	users := testArchiveCollection("1a", "users")
+	orders := testArchiveCollection("2b", "orders")
+
+	jsonPath := filepath.Join(dir, "users.json")
+	writeTestJSON(t, jsonPath, users)
+
+	dumpPath := filepath.Join(dir, "dump.json")
+	writeTestJSON(t, dumpPath, map[string]any{
+		"version":      1,
+		"collections":  []any{users, orders},
+		"environments": []any{map[string]any{"name": "dev", "values": []any{}}},
+	})
+
+	gzPath := filepath.Join(dir, "users.json.gz")
+	gzFile, err := os.Create(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(gzFile)
+	if err := json.NewEncoder(gz).Encode(users); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	gzFile.Close()
+
+	zipPath := filepath.Join(dir, "export.zip")
+	writeTestZip(t, zipPath, map[string]any{
+		"archive.json":           map[string]any{"collection": map[string]any{"1a": true, "2b": true}},
+		"collection/1a.json":     users,
+		"collection/2b.json":     orders,
+		"environment/dev.json":   map[string]any{"name": "dev", "values": []any{}},
+		"collection/readme.txt":  "not JSON",
+		"collection/nested.json": map[string]any{"collections": []any{testArchiveCollection("3c", "nested")}},
+	})
+
+	tests := []struct {
+		path      string
+		wantNames []string
+	}{
+		{jsonPath, []string{"users"}},
+		{dumpPath, []string{"users", "orders"}},
+		{gzPath, []string{"users"}},
+		{zipPath, []string{"nested", "users", "orders"}},
+	}
+
+	for _, test := range tests {
+		t.Run(filepath.Base(test.path), func(t *testing.T) {
+			collections, err := readCollections(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotNames := collectionNames(collections)
+			if len(gotNames) != len(test.wantNames) {
+				t.Fatalf("readCollections(%q) names = %q, want %q", test.path, gotNames, test.wantNames)
+			}
+			for _, want := range test.wantNames {
+				if !strings.Contains(strings.Join(gotNames, "\n")+"\n", want+"\n") {
+					t.Errorf("readCollections(%q) names = %q, want %q", test.path, gotNames, test.wantNames)
+				}
+			}
+		})
+	}
+}
+
+func TestReadCollectionsErrors(t *testing.T) {
+	dir := t.TempDir()
+	notCollectionPath := filepath.Join(dir, "other.json")
+	writeTestJSON(t, notCollectionPath, map[string]any{
+		"version":     1,
+		"collections": []any{map[string]any{"id": "1a", "name": "other"}},
+	})
+	emptyZipPath := filepath.Join(dir, "empty.zip")
+	writeTestZip(t, emptyZipPath, map[string]any{"environment/dev.json": map[string]any{"name": "dev"}})
+
+	tests := []struct {
+		path, wantErr string
+	}{
+		{notCollectionPath, "no collection info found"},
+		{emptyZipPath, "no collections found"},
+	}
+
+	for _, test := range tests {
+		t.Run(filepath.Base(test.path), func(t *testing.T) {
+			_, err := readCollections(test.path)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("readCollections(%q) error = %v, want an error containing %q", test.path, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelectCollections(t *testing.T) {
+	collections := []map[string]any{
+		testArchiveCollection("1a", "users"),
+		testArchiveCollection("2b", "orders"),
+		testArchiveCollection("3c", "orders"),
+	}
+
+	tests := []struct {
+		name       string
+		namesOrIDs []string
+		wantNames  []string
+		wantErr    string
+	}{
+		{"by name", []string{"users"}, []string{"users"}, ""},
+		{"by ID", []string{"3c"}, []string{"orders"}, ""},
+		{"several", []string{"3c", "users"}, []string{"orders", "users"}, ""},
+		{"none chosen", nil, nil, "3 collections found"},
+		{"unknown", []string{"payments"}, nil, "no collection found"},
+		{"ambiguous name", []string{"orders"}, nil, "Choose one by ID"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected, err := selectCollections(collections, test.namesOrIDs)
+			if len(test.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("selectCollections(%q) error = %v, want an error containing %q", test.namesOrIDs, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotNames := collectionNames(selected)
+			if strings.Join(gotNames, ",") != strings.Join(test.wantNames, ",") {
+				t.Errorf("selectCollections(%q) names = %q, want %q", test.namesOrIDs, gotNames, test.wantNames)
+			}
+		})
+	}
+}
+
+func TestReadSelectedCollectionMerges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.zip")
+	writeTestZip(t, path, map[string]any{
+		"collection/1a.json": testArchiveCollection("1a", "users"),
+		"collection/2b.json": testArchiveCollection("2b", "orders"),
+	})
+
+	collection, err := readSelectedCollection(path, []string{"orders", "1a"}, "API")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := collectionName(collection); name != "API" {
+		t.Errorf("merged collection name = %q, want %q", name, "API")
+	}
+	items := collection["item"].([]any)
+	if len(items) != 2 || items[0].(map[string]any)["name"] != "orders" || items[1].(map[string]any)["name"] != "users" {
+		t.Errorf("merged collection items = %v, want folders orders and users", items)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// atomicFile is output that only reaches its destination if all of it is written
+// successfully. Output to a file is written to a temporary file in the same directory,
+// which commit renames to the destination path. Output to stdout, which has the path
+// "-", is buffered until commit. Until commit is called, the destination is unchanged.
+type atomicFile struct {
+	path string
+	temp *os.File // nil if the destination is stdout
+	buf  bytes.Buffer
+}
+
+// createAtomicFile starts output to the given destination path, or to stdout if the
+// path is "-". Either commit or abort must be called on the result.
+func createAtomicFile(path string) (*atomicFile, error) {
+	if path == "-" {
+		return &atomicFile{path: path}, nil
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{path: path, temp: temp}, nil
+}
+
+func (f *atomicFile) Write(p []byte) (int, error) {
+	if f.temp == nil {
+		return f.buf.Write(p)
+	}
+	return f.temp.Write(p)
+}
+
+// commit saves the output to the destination. If the destination is an existing file,
+// the file's permissions are kept. If commit fails, the destination is unchanged.
+func (f *atomicFile) commit() error {
+	if f.temp == nil {
+		_, err := os.Stdout.Write(f.buf.Bytes())
+		return err
+	}
+	var perm fs.FileMode = 0644
+	if info, err := os.Stat(f.path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	err := f.temp.Chmod(perm)
+	if err == nil {
+		err = f.temp.Sync()
+	}
+	if closeErr := f.temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.temp.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.temp.Name())
+	}
+	return err
+}
+
+// abort discards the output without changing the destination.
+func (f *atomicFile) abort() {
+	if f.temp == nil {
+		f.buf.Reset()
+		return
+	}
+	f.temp.Close()
+	os.Remove(f.temp.Name())
+}
+
+// writeFileAtomic saves data to the file at the given path, or prints it to stdout if the
+// path is "-". The file is either completely written or unchanged.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := createAtomicFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.abort()
+		return err
+	}
+	return f.commit()
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// assertOnlyFiles asserts a directory contains only files with the given names.
+func assertOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	if len(got) != len(names) {
+		t.Errorf("directory contains %q, want %q", got, names)
+		return
+	}
+	for i := range got {
+		if got[i] != names[i] {
+			t.Errorf("directory contains %q, want %q", got, names)
+			return
+		}
+	}
+}
+
+func TestAtomicFileCommit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "docs.md")
+	if err := os.WriteFile(path, []byte("old docs"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := createAtomicFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "new docs"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "old docs" {
+		t.Errorf("before commit, file contains %q, want %q", b, "old docs")
+	}
+	if err := f.commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := os.ReadFile(path); string(b) != "new docs" {
+		t.Errorf("after commit, file contains %q, want %q", b, "new docs")
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0o600 {
+		t.Errorf("after commit, file permissions are %v, want %v", info.Mode().Perm(), os.FileMode(0o600))
+	}
+	assertOnlyFiles(t, dir, "docs.md")
+}
+
+func TestAtomicFileAbort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "docs.md")
+
+	f, err := createAtomicFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "partial docs"); err != nil {
+		t.Fatal(err)
+	}
+	f.abort()
+
+	assertOnlyFiles(t, dir)
+}
+
+func TestGenerateTargetKeepsOutputOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "docs.md")
+	tmplPath := filepath.Join(dir, "broken.tmpl")
+	if err := os.WriteFile(outputPath, []byte("old docs"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tmplPath, []byte("# {{ .info.name }}\n{{ index .item 99 }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := generateTarget(Target{
+		Input:    "../samples/calendar-API.postman_collection.json",
+		Output:   outputPath,
+		Template: TemplateList{tmplPath},
+		Replace:  true,
+	})
+	if err == nil {
+		t.Fatal("generateTarget with a failing template returned nil error, want non-nil error")
+	}
+
+	if b, _ := os.ReadFile(outputPath); string(b) != "old docs" {
+		t.Errorf("after a failed conversion, output contains %q, want %q", b, "old docs")
+	}
+	assertOnlyFiles(t, dir, "broken.tmpl", "docs.md")
+}
+
+func TestGenerateTargetStdoutOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "broken.tmpl")
+	if err := os.WriteFile(tmplPath, []byte("# {{ .info.name }}\n{{ index .item 99 }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	_, err = generateTarget(Target{
+		Input:    "../samples/calendar-API.postman_collection.json",
+		Output:   "-",
+		Template: TemplateList{tmplPath},
+	})
+	os.Stdout = stdout
+	w.Close()
+	if err == nil {
+		t.Error("generateTarget with a failing template returned nil error, want non-nil error")
+	}
+
+	printed, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(printed) > 0 {
+		t.Errorf("after a failed conversion, stdout received %q, want nothing", printed)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultOutputPattern = "{name}.md"
+
+var OutputPattern string
+var Jobs int
+var KeepExisting bool
+
+var batchCmd = &cobra.Command{
+	Use:   "batch [folder or glob...]",
+	Short: "Convert many collections at once",
+	Long: "Convert many collections at once\n\n" +
+		"Each argument is a collection file, a glob such as \"collections/*.json\", or a folder\n" +
+		"that is searched for .json, .json.gz, and .zip files. Each output path comes from the\n" +
+		"output pattern, in which {name} is the input file's name without its extension, {dir}\n" +
+		"is the input file's folder, and {collection} is the collection's name. Existing output\n" +
+		"files are replaced unless --keep-existing is used.",
+	Example: `  pm2md batch collections
+  pm2md batch "collections/*.json" --output="docs/{name}.md" --jobs=4`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: batchRunFunc,
+}
+
+// batchJob is one collection to convert during a batch conversion.
+type batchJob struct {
+	target Target
+	err    error
+}
+
+// batchRunFunc finds all the chosen collections, converts them concurrently, and prints
+// a summary of the results.
+func batchRunFunc(cmd *cobra.Command, args []string) error {
+	if err := flagTarget().validate(); err != nil {
+		return err
+	}
+	if len(Inject) > 0 {
+		return fmt.Errorf("--inject can't be used with the batch subcommand")
+	}
+	if Jobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1, got %d", Jobs)
+	}
+	inputPaths, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	start := time.Now()
+	batch := make([]batchJob, len(inputPaths))
+	for i, inputPath := range inputPaths {
+		batch[i].target = flagTarget().withOverrides(Target{Input: inputPath, KeepExisting: KeepExisting})
+	}
+	runBatch(batch, OutputPattern, Jobs)
+
+	failCount := 0
+	for _, job := range batch {
+		if job.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", job.target.Input, job.err)
+			failCount++
+		} else {
+			fmt.Fprintf(os.Stderr, "Created %q\n", job.target.Output)
+		}
+	}
+	fmt.Fprintf(
+		os.Stderr,
+		"Converted %d of %d collections in %s\n",
+		len(batch)-failCount, len(batch), time.Since(start).Round(time.Millisecond),
+	)
+	if failCount > 0 {
+		return fmt.Errorf("%d of %d collections failed", failCount, len(batch))
+	}
+
+	return nil
+}
+
+// expandInputs converts the given collection file paths, globs, and folders to a list of
+// collection file paths. Folders are searched recursively for the files that
+// isCollectionPath accepts. An error is returned if any glob or folder has no such files.
+func expandInputs(args []string) ([]string, error) {
+	inputPaths := make([]string, 0, len(args))
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		var matches []string
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && isCollectionPath(path) {
+					matches = append(matches, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %s", arg, err)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no collection files found for %q", arg)
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				inputPaths = append(inputPaths, match)
+			}
+		}
+	}
+
+	return inputPaths, nil
+}
+
+// formatOutputPath replaces the placeholders in an output pattern with values based on
+// the input path and the collection name.
+func formatOutputPath(pattern, inputPath, collectionName string) string {
+	name := filepath.Base(inputPath)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = strings.TrimSuffix(name, ".postman_collection")
+	collectionFileName := FormatFileName(collectionName)
+	if len(collectionFileName) == 0 {
+		collectionFileName = "collection"
+	}
+
+	return filepath.Clean(strings.NewReplacer(
+		"{name}", name,
+		"{dir}", filepath.Dir(inputPath),
+		"{collection}", collectionFileName,
+	).Replace(pattern))
+}
+
+// runBatch converts each job's collection with a pool of the given number of workers.
+// Each job's output path is set from the output pattern, and any error is saved in the
+// job. Two jobs with the same output path are both marked as failed without either of
+// their outputs being created. A batch is meant to be run again whenever collections
+// change, so existing outputs are replaced unless a job's target keeps existing files.
+func runBatch(batch []batchJob, outputPattern string, workers int) {
+	outputs := make(map[string][]int)
+	for i := range batch {
+		job := &batch[i]
+		name := ""
+		if strings.Contains(outputPattern, "{collection}") {
+			collection, err := readSelectedCollection(job.target.Input, job.target.Collections, job.target.Title)
+			if err != nil {
+				job.err = err
+				continue
+			}
+			name = collectionName(collection)
+			if len(name) == 0 {
+				job.err = fmt.Errorf("the collection has no name for {collection} in the output pattern")
+				continue
+			}
+		}
+		if !job.target.KeepExisting {
+			job.target.Replace = true
+		}
+		job.target.Output = formatOutputPath(outputPattern, job.target.Input, name)
+		outputs[job.target.Output] = append(outputs[job.target.Output], i)
+	}
+	for output, indexes := range outputs {
+		if len(indexes) > 1 {
+			for _, i := range indexes {
+				batch[i].err = fmt.Errorf("%d inputs have the same output path %q", len(indexes), output)
+			}
+		}
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				job := &batch[i]
+				if err := os.MkdirAll(filepath.Dir(job.target.Output), 0o755); err != nil {
+					job.err = err
+					continue
+				}
+				_, job.err = generateTarget(job.target)
+			}
+		}()
+	}
+	for i := range batch {
+		if batch[i].err == nil {
+			indexes <- i
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
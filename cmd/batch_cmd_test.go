@@ -0,0 +1,186 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.json", "notes.txt", filepath.Join("sub", "c.JSON"), "d.json.gz", "export.zip"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"folder", []string{dir}, []string{"a.json", "b.json", "d.json.gz", "export.zip", filepath.Join("sub", "c.JSON")}},
+		{"glob", []string{filepath.Join(dir, "*.json")}, []string{"a.json", "b.json"}},
+		{"duplicates", []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "*.json")}, []string{"a.json", "b.json"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ans, err := expandInputs(test.args)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			want := make([]string, len(test.want))
+			for i, name := range test.want {
+				want[i] = filepath.Join(dir, name)
+			}
+			slices.Sort(ans)
+			if !reflect.DeepEqual(ans, want) {
+				t.Errorf("expandInputs(%q) = %q, want %q", test.args, ans, want)
+			}
+		})
+	}
+}
+
+func TestExpandInputsWithNoMatches(t *testing.T) {
+	args := []string{filepath.Join(t.TempDir(), "*.json")}
+	if ans, err := expandInputs(args); err == nil {
+		t.Errorf("expandInputs(%q) = (%q, nil), want non-nil error", args, ans)
+	}
+}
+
+func TestFormatOutputPath(t *testing.T) {
+	tests := []struct {
+		pattern, inputPath, collectionName, want string
+	}{
+		{"{name}.md", "collections/billing.json", "", "billing.md"},
+		{"docs/{name}.md", "collections/calendar-API.postman_collection.json", "", "docs/calendar-API.md"},
+		{"{dir}/{name}.md", "collections/v1/users.json", "", "collections/v1/users.md"},
+		{"docs/{collection}.md", "a.json", "calendar API", "docs/calendar-API.md"},
+		{"docs/{collection}.md", "a.json", "", "docs/collection.md"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			ans := formatOutputPath(test.pattern, test.inputPath, test.collectionName)
+			if ans != filepath.FromSlash(test.want) {
+				t.Errorf(
+					"formatOutputPath(%q, %q, %q) = %q, want %q",
+					test.pattern, test.inputPath, test.collectionName, ans, test.want,
+				)
+			}
+		})
+	}
+}
+
+func TestRunBatchConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	batch := make([]batchJob, 8)
+	for i := range batch {
+		inputPath := filepath.Join(dir, strings.Repeat("x", i+1)+".json")
+		jsonBytes, err := os.ReadFile("../samples/calendar-API.postman_collection.json")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if err := os.WriteFile(inputPath, jsonBytes, 0o644); err != nil {
+			t.Error(err)
+			return
+		}
+		batch[i].target = Target{Input: inputPath}
+	}
+
+	runBatch(batch, filepath.Join(dir, "out", "{name}.md"), 4)
+
+	wantBytes, err := os.ReadFile("../samples/calendar-API-v1.md")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := strings.ReplaceAll(string(wantBytes), "\r\n", "\n")
+	for _, job := range batch {
+		if job.err != nil {
+			t.Error(job.err)
+			continue
+		}
+		ansBytes, err := os.ReadFile(job.target.Output)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		ans := strings.ReplaceAll(string(ansBytes), "\r\n", "\n")
+		if err := AssertNoDiff(ans, want, "\n"); err != nil {
+			t.Errorf("%s: %s", job.target.Output, err)
+		}
+	}
+}
+
+func TestRunBatchWithSameOutputPaths(t *testing.T) {
+	dir := t.TempDir()
+	batch := []batchJob{
+		{target: Target{Input: "a/api.json"}},
+		{target: Target{Input: "b/api.json"}},
+	}
+	runBatch(batch, filepath.Join(dir, "{name}.md"), 2)
+	for _, job := range batch {
+		if job.err == nil {
+			t.Errorf("job for %q has nil error, want non-nil error", job.target.Input)
+		}
+	}
+}
+
+func TestRunBatchTwice(t *testing.T) {
+	dir := t.TempDir()
+	for _, keepExisting := range []bool{false, true} {
+		for run := 1; run <= 2; run++ {
+			batch := []batchJob{
+				{target: Target{Input: "../samples/calendar-API.postman_collection.json", KeepExisting: keepExisting}},
+			}
+			pattern := filepath.Join(dir, fmt.Sprintf("keep-%v", keepExisting), "{name}.md")
+			runBatch(batch, pattern, 1)
+			wantErr := keepExisting && run == 2
+			if err := batch[0].err; (err != nil) != wantErr {
+				t.Errorf("run %d with KeepExisting %v: error = %v, want error: %v", run, keepExisting, err, wantErr)
+			}
+		}
+	}
+}
+
+func TestRunBatchWithUnnamedCollection(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "x.json")
+	writeTestJSON(t, inputPath, map[string]any{
+		"info": map[string]any{"schema": collectionSchema},
+		"item": []any{},
+	})
+	batch := []batchJob{{target: Target{Input: inputPath}}}
+	runBatch(batch, filepath.Join(dir, "out", "{collection}.md"), 1)
+	if err := batch[0].err; err == nil || !strings.Contains(err.Error(), "no name") {
+		t.Errorf("error = %v, want an error about the collection having no name", err)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build [target...]",
+	Short: "Generate the targets defined in a " + configFileName + " file",
+	Long: fmt.Sprintf(
+		"Generate the targets defined in a %s file\n\n"+
+			"The file is searched for in the current folder and then in each parent folder.\n"+
+			"Without arguments, every target is generated. Flags override the targets' values.",
+		configFileName,
+	),
+	RunE: buildRunFunc,
+}
+
+// buildRunFunc finds and loads the configuration file, and generates each chosen target
+// with the command's flags overriding the targets' values.
+func buildRunFunc(cmd *cobra.Command, args []string) error {
+	configPath, err := findConfig(".")
+	if err != nil {
+		return err
+	}
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = config.targetNames()
+	}
+	targets := make([]Target, len(names))
+	for i, name := range names {
+		targets[i], err = buildTarget(config, name, flagTarget())
+		if err != nil {
+			return err
+		}
+	}
+
+	failCount := 0
+	for i, target := range targets {
+		destPath, err := generateTarget(target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", names[i], err)
+			failCount++
+		} else if destPath != "-" && len(target.Inject) == 0 {
+			fmt.Fprintf(os.Stderr, "Created %q\n", destPath)
+		}
+	}
+	if failCount > 0 {
+		return fmt.Errorf("%d of %d targets failed", failCount, len(targets))
+	}
+
+	return nil
+}
+
+// buildTarget returns the target with the given name with the given overrides, ready to
+// generate. A build is meant to be run again whenever a collection changes, so the
+// target's output is replaced if it already exists, unless the target keeps existing
+// files and isn't overridden to replace them.
+func buildTarget(config *Config, name string, overrides Target) (Target, error) {
+	target, err := config.target(name)
+	if err != nil {
+		return Target{}, err
+	}
+	target = target.withOverrides(overrides)
+	if !target.KeepExisting {
+		target.Replace = true
+	}
+	if err := target.validate(); err != nil {
+		return Target{}, fmt.Errorf("target %q: %s", name, err)
+	}
+	return target, nil
+}
+
+// generateTarget reads a target's input, converts it to plaintext with the target's
+// options, and saves the result to the target's output or injects it into the target's
+// inject file. The returned path is the path of the output or inject file.
+func generateTarget(target Target) (string, error) {
+	opts, err := target.renderOptions()
+	if err != nil {
+		return "", err
+	}
+	collection, err := loadCollection(target)
+	if err != nil {
+		return "", err
+	}
+
+	if len(target.Inject) > 0 {
+		var b strings.Builder
+		if err := renderText(collection, &b, opts); err != nil {
+			return "", err
+		}
+		return target.Inject, injectFile(target.Inject, b.String(), target.Marker, target.Check)
+	}
+
+	collectionName := collection["info"].(map[string]any)["name"].(string)
+	destFile, destPath, err := openDestFile(target.Output, collectionName, target.Replace)
+	if err != nil {
+		return "", err
+	}
+	if err := renderText(collection, destFile, opts); err != nil {
+		destFile.abort()
+		return "", err
+	}
+	if err := destFile.commit(); err != nil {
+		return "", err
+	}
+	return destPath, nil
+}
+
+// loadCollection reads a target's input, merges its inputs, or fetches its collection
+// from the Postman API, replaces variables with the values in the target's environment,
+// and applies the target's redaction rules. Each redaction is reported to stderr.
+func loadCollection(target Target) (map[string]any, error) {
+	rules, err := target.redactRules()
+	if err != nil {
+		return nil, err
+	}
+	var client *postmanClient
+	if len(target.CollectionID) > 0 || len(target.EnvironmentID) > 0 {
+		client, err = newPostmanClient(target)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var collection map[string]any
+	if len(target.CollectionID) > 0 {
+		collection, err = client.fetchCollection(target.CollectionID)
+	} else if len(target.Inputs) > 0 {
+		collection, err = readMergedCollection(target.Inputs, target.Title)
+	} else {
+		collection, err = readSelectedCollection(target.Input, target.Collections, target.Title)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(target.EnvFile) > 0 || len(target.EnvironmentID) > 0 {
+		var vars map[string]string
+		if len(target.EnvironmentID) > 0 {
+			vars, err = client.fetchEnvironment(target.EnvironmentID)
+		} else {
+			vars, err = loadEnvironment(target.EnvFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+		applyEnvironment(collection, vars)
+	}
+	if rules != nil {
+		inputName := target.Input
+		if inputName == "-" {
+			inputName = "stdin"
+		} else if len(target.Inputs) > 0 {
+			inputName = "merged collections"
+		} else if len(target.CollectionID) > 0 {
+			inputName = fmt.Sprintf("collection %q", target.CollectionID)
+		}
+		for _, redaction := range redactCollection(collection, rules) {
+			fmt.Fprintf(os.Stderr, "%s: redacted %s\n", inputName, redaction)
+		}
+	}
+
+	return collection, nil
+}
+
+// readMergedCollection reads collections from JSON files and merges them into one
+// collection with the given title.
+func readMergedCollection(inputs []MergeInput, title string) (map[string]any, error) {
+	collections := make([]map[string]any, len(inputs))
+	prefixes := make([]string, len(inputs))
+	for i, input := range inputs {
+		collection, err := readCollection(input.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", input.Path, err)
+		}
+		collections[i] = collection
+		prefixes[i] = input.Prefix
+	}
+	return mergeCollections(collections, prefixes, title), nil
+}
@@ -0,0 +1,329 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isV1Collection reports whether a decoded collection uses the Collection v1 format,
+// which Postman's "Export data" still uses for the collections in its data dumps.
+func isV1Collection(collection map[string]any) bool {
+	_, hasInfo := collection["info"]
+	_, hasRequests := collection["requests"]
+	return !hasInfo && hasRequests
+}
+
+// convertV1Collection converts a collection in the Collection v1 format to the
+// Collection v2.1 format. Folders are nested as their "folders_order" properties say,
+// and each folder's endpoints and the collection's outermost endpoints are in the order
+// their "order" properties say. Folders come before endpoints, as they do when Postman
+// converts a collection. Requests that no order lists are added to the folder their
+// "folder" property names, or to the collection if there's no such folder.
+func convertV1Collection(v1 map[string]any) map[string]any {
+	info := map[string]any{
+		"_postman_id": fmt.Sprint(v1["id"]),
+		"name":        fmt.Sprint(v1["name"]),
+		"schema":      collectionSchema,
+	}
+	if description, ok := v1["description"].(string); ok && len(description) > 0 {
+		info["description"] = description
+	}
+
+	requests := make(map[string]map[string]any)
+	var requestIDs []string
+	for _, requestAny := range v1List(v1["requests"]) {
+		if request, ok := requestAny.(map[string]any); ok {
+			id := fmt.Sprint(request["id"])
+			requests[id] = request
+			requestIDs = append(requestIDs, id)
+		}
+	}
+	folders := make(map[string]map[string]any)
+	var folderIDs []string
+	for _, folderAny := range v1List(v1["folders"]) {
+		if folder, ok := folderAny.(map[string]any); ok {
+			id := fmt.Sprint(folder["id"])
+			folders[id] = folder
+			folderIDs = append(folderIDs, id)
+		}
+	}
+
+	// Each request and folder that an order lists belongs where it's listed.
+	ordered := make(map[string]bool)
+	for _, folder := range folders {
+		for _, id := range v1Strings(folder["order"]) {
+			ordered[id] = true
+		}
+		for _, id := range v1Strings(folder["folders_order"]) {
+			ordered[id] = true
+		}
+	}
+	rootFolderIDs := v1Strings(v1["folders_order"])
+	if _, ok := v1["folders_order"]; !ok {
+		for _, id := range folderIDs {
+			if !ordered[id] {
+				rootFolderIDs = append(rootFolderIDs, id)
+			}
+		}
+	}
+	rootRequestIDs := v1Strings(v1["order"])
+	for _, id := range rootRequestIDs {
+		ordered[id] = true
+	}
+	unordered := make(map[string][]string)
+	for _, id := range requestIDs {
+		if !ordered[id] {
+			folderID := fmt.Sprint(requests[id]["folder"])
+			if _, ok := folders[folderID]; !ok {
+				folderID = ""
+			}
+			unordered[folderID] = append(unordered[folderID], id)
+		}
+	}
+
+	c := v1Converter{requests: requests, folders: folders, unordered: unordered, seen: make(map[string]bool)}
+	return map[string]any{
+		"info": info,
+		"item": c.items(rootFolderIDs, append(rootRequestIDs, unordered[""]...)),
+	}
+}
+
+// v1Converter converts the folders and requests of a Collection v1 to items.
+type v1Converter struct {
+	requests map[string]map[string]any
+	folders  map[string]map[string]any
+
+	// unordered is the IDs of the requests that no order lists, by the IDs of their
+	// folders.
+	unordered map[string][]string
+
+	// seen is the IDs of the folders that have been converted, so that a folder that
+	// lists itself as a subfolder can't cause endless recursion.
+	seen map[string]bool
+}
+
+// items converts the folders and requests with the given IDs to items. IDs that aren't
+// in the collection are ignored.
+func (c v1Converter) items(folderIDs, requestIDs []string) []any {
+	items := make([]any, 0, len(folderIDs)+len(requestIDs))
+	for _, id := range folderIDs {
+		folder, ok := c.folders[id]
+		if !ok || c.seen[id] {
+			continue
+		}
+		c.seen[id] = true
+		item := map[string]any{
+			"name": fmt.Sprint(folder["name"]),
+			"item": c.items(
+				v1Strings(folder["folders_order"]),
+				append(v1Strings(folder["order"]), c.unordered[id]...),
+			),
+		}
+		if description, ok := folder["description"].(string); ok && len(description) > 0 {
+			item["description"] = description
+		}
+		items = append(items, item)
+	}
+	for _, id := range requestIDs {
+		if request, ok := c.requests[id]; ok {
+			items = append(items, convertV1Request(request))
+		}
+	}
+	return items
+}
+
+// convertV1Request converts a Collection v1 request with its sample responses to an
+// endpoint item.
+func convertV1Request(v1 map[string]any) map[string]any {
+	rawURL, _ := v1["url"].(string)
+	request := map[string]any{
+		"method": strings.ToUpper(fmt.Sprint(v1["method"])),
+		"header": convertV1Headers(v1["headerData"], v1["headers"]),
+		"url":    convertV1URL(rawURL, v1["queryParams"], v1["pathVariableData"]),
+	}
+	if description, ok := v1["description"].(string); ok && len(description) > 0 {
+		request["description"] = description
+	}
+	if body := convertV1Body(v1); body != nil {
+		request["body"] = body
+	}
+
+	responses := make([]any, 0)
+	for _, responseAny := range v1List(v1["responses"]) {
+		if response, ok := responseAny.(map[string]any); ok {
+			responses = append(responses, convertV1Response(response))
+		}
+	}
+	return map[string]any{
+		"name":     fmt.Sprint(v1["name"]),
+		"request":  request,
+		"response": responses,
+	}
+}
+
+// convertV1URL converts a URL such as "{{base_url}}/v1/users?page=2" to a collection
+// URL.
+func convertV1URL(rawURL string, queryParams, pathVariables any) map[string]any {
+	url := map[string]any{"raw": rawURL}
+	rest := rawURL
+	if _, afterScheme, ok := strings.Cut(rest, "://"); ok {
+		rest = afterScheme
+	}
+	rest, query, _ := strings.Cut(rest, "?")
+	host, path, _ := strings.Cut(rest, "/")
+	if len(host) > 0 {
+		url["host"] = v1Segments(host, ".")
+	}
+	url["path"] = v1Segments(path, "/")
+	if params := convertV1Pairs(queryParams); len(params) > 0 {
+		url["query"] = params
+	} else if len(query) > 0 {
+		params := make([]any, 0)
+		for _, pair := range strings.Split(query, "&") {
+			key, value, _ := strings.Cut(pair, "=")
+			params = append(params, map[string]any{"key": key, "value": value})
+		}
+		url["query"] = params
+	}
+	if variables := convertV1Pairs(pathVariables); len(variables) > 0 {
+		url["variable"] = variables
+	}
+	return url
+}
+
+// convertV1Headers converts a Collection v1 request's headers to a collection's
+// headers. The headers are in headerData, or if there is none, in headers as text with
+// one "key: value" header per line.
+func convertV1Headers(headerData, headers any) []any {
+	if result := convertV1Pairs(headerData); len(result) > 0 {
+		return result
+	}
+	result := make([]any, 0)
+	text, _ := headers.(string)
+	for _, line := range strings.Split(text, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && len(strings.TrimSpace(key)) > 0 {
+			result = append(result, map[string]any{
+				"key":   strings.TrimSpace(key),
+				"value": strings.TrimSpace(value),
+			})
+		}
+	}
+	return result
+}
+
+// convertV1Body converts a Collection v1 request's body to a collection request body,
+// or returns nil if the request has no body.
+func convertV1Body(v1 map[string]any) map[string]any {
+	switch v1["dataMode"] {
+	case "raw":
+		raw, _ := v1["rawModeData"].(string)
+		if len(raw) == 0 {
+			return nil
+		}
+		return map[string]any{"mode": "raw", "raw": raw}
+	case "params":
+		if data := convertV1Pairs(v1["data"]); len(data) > 0 {
+			return map[string]any{"mode": "formdata", "formdata": data}
+		}
+	case "urlencoded":
+		if data := convertV1Pairs(v1["data"]); len(data) > 0 {
+			return map[string]any{"mode": "urlencoded", "urlencoded": data}
+		}
+	}
+	return nil
+}
+
+// convertV1Response converts a Collection v1 sample response to a collection response.
+func convertV1Response(v1 map[string]any) map[string]any {
+	response := map[string]any{
+		"name":                     fmt.Sprint(v1["name"]),
+		"code":                     float64(0),
+		"status":                   "",
+		"header":                   convertV1Pairs(v1["headers"]),
+		"body":                     "",
+		"_postman_previewlanguage": "",
+	}
+	if responseCode, ok := v1["responseCode"].(map[string]any); ok {
+		if code, ok := responseCode["code"].(float64); ok {
+			response["code"] = code
+		}
+		if status, ok := responseCode["name"].(string); ok {
+			response["status"] = status
+		}
+	}
+	if body, ok := v1["text"].(string); ok {
+		response["body"] = body
+	}
+	if language, ok := v1["language"].(string); ok {
+		response["_postman_previewlanguage"] = language
+	}
+	return response
+}
+
+// convertV1Pairs converts a Collection v1 list of keys and values, such as headers or
+// query parameters, to a collection's list of keys and values. Pairs that are disabled
+// stay disabled.
+func convertV1Pairs(pairsAny any) []any {
+	result := make([]any, 0)
+	for _, pairAny := range v1List(pairsAny) {
+		pair, ok := pairAny.(map[string]any)
+		if !ok {
+			continue
+		}
+		converted := map[string]any{"key": fmt.Sprint(pair["key"]), "value": ""}
+		if value, ok := pair["value"].(string); ok {
+			converted["value"] = value
+		}
+		if description, ok := pair["description"].(string); ok && len(description) > 0 {
+			converted["description"] = description
+		}
+		if enabled, ok := pair["enabled"].(bool); ok && !enabled {
+			converted["disabled"] = true
+		}
+		result = append(result, converted)
+	}
+	return result
+}
+
+// v1List returns a decoded JSON list, or nil if the value isn't a list.
+func v1List(value any) []any {
+	list, _ := value.([]any)
+	return list
+}
+
+// v1Strings returns the strings in a decoded JSON list, such as a list of IDs.
+func v1Strings(value any) []string {
+	var result []string
+	for _, elem := range v1List(value) {
+		if s, ok := elem.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// v1Segments splits text into segments, leaving out empty ones.
+func v1Segments(text, sep string) []any {
+	segments := make([]any, 0)
+	for _, segment := range strings.Split(text, sep) {
+		if len(segment) > 0 {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
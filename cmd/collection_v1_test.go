@@ -0,0 +1,172 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testV1Dump returns a data dump like one from Postman's "Export data", which has its
+// collections in the Collection v1 format.
+func testV1Dump() map[string]any {
+	return map[string]any{
+		"version": 1,
+		"collections": []any{
+			map[string]any{
+				"id":            "1a",
+				"name":          "users",
+				"description":   "Manage users.",
+				"order":         []any{"r3"},
+				"folders_order": []any{"f1"},
+				"folders": []any{
+					map[string]any{
+						"id":            "f1",
+						"name":          "accounts",
+						"description":   "Account endpoints.",
+						"order":         []any{"r2", "r1"},
+						"folders_order": []any{"f2"},
+					},
+					map[string]any{
+						"id":    "f2",
+						"name":  "admin",
+						"order": []any{},
+					},
+				},
+				"requests": []any{
+					map[string]any{
+						"id":          "r1",
+						"name":        "create user",
+						"description": "Creates a user.",
+						"method":      "post",
+						"url":         "{{base_url}}/v1/users",
+						"headerData":  []any{map[string]any{"key": "Content-Type", "value": "application/json"}},
+						"dataMode":    "raw",
+						"rawModeData": `{"name": "Ann"}`,
+						"responses": []any{
+							map[string]any{
+								"name":         "created",
+								"responseCode": map[string]any{"code": float64(201), "name": "Created"},
+								"text":         `{"id": 1}`,
+								"language":     "json",
+							},
+						},
+					},
+					map[string]any{
+						"id":      "r2",
+						"name":    "list users",
+						"method":  "GET",
+						"url":     "https://api.example.com/v1/users?page=2",
+						"headers": "Accept: application/json\n",
+					},
+					map[string]any{
+						"id":     "r3",
+						"name":   "health",
+						"method": "GET",
+						"url":    "https://api.example.com/health",
+					},
+					map[string]any{
+						"id":     "r4",
+						"name":   "delete user",
+						"method": "DELETE",
+						"url":    "{{base_url}}/v1/users/:id",
+						"folder": "f2",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestReadV1Dump(t *testing.T) {
+	dumpPath := filepath.Join(t.TempDir(), "backup.json")
+	writeTestJSON(t, dumpPath, testV1Dump())
+	collections, err := readCollections(dumpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collections) != 1 {
+		t.Fatalf("readCollections found %d collections, want 1", len(collections))
+	}
+	collection := collections[0]
+	if name, id := collectionName(collection), collectionID(collection); name != "users" || id != "1a" {
+		t.Errorf("collection name and ID = %q and %q, want \"users\" and \"1a\"", name, id)
+	}
+
+	var names []string
+	var walk func(items []any, prefix string)
+	walk = func(items []any, prefix string) {
+		for _, itemAny := range items {
+			item := itemAny.(map[string]any)
+			names = append(names, prefix+item["name"].(string))
+			if subItems, ok := item["item"].([]any); ok {
+				walk(subItems, prefix+item["name"].(string)+"/")
+			}
+		}
+	}
+	walk(collection["item"].([]any), "")
+	wantNames := []string{
+		"accounts", "accounts/admin", "accounts/admin/delete user",
+		"accounts/list users", "accounts/create user", "health",
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("item names = %q, want %q", names, wantNames)
+	}
+
+	accounts := collection["item"].([]any)[0].(map[string]any)
+	create := accounts["item"].([]any)[2].(map[string]any)
+	request := create["request"].(map[string]any)
+	if request["method"] != "POST" {
+		t.Errorf("method = %v, want POST", request["method"])
+	}
+	if path := request["url"].(map[string]any)["path"]; !reflect.DeepEqual(path, []any{"v1", "users"}) {
+		t.Errorf("URL path = %v, want [v1 users]", path)
+	}
+	if raw := jsonPath("body.raw", request); raw != `{"name": "Ann"}` {
+		t.Errorf("body = %v, want the raw body", raw)
+	}
+	response := create["response"].([]any)[0].(map[string]any)
+	if response["code"] != float64(201) || response["status"] != "Created" || response["body"] != `{"id": 1}` {
+		t.Errorf("response = %v, want code 201, status Created, and the body", response)
+	}
+}
+
+func TestRenderV1Dump(t *testing.T) {
+	dumpPath := filepath.Join(t.TempDir(), "backup.json")
+	writeTestJSON(t, dumpPath, testV1Dump())
+	collection, err := readCollection(dumpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := renderText(collection, &b, renderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"# users\n\nManage users.",
+		"<h1>accounts</h1> - Account endpoints.",
+		"POST `/v1/users`\n\nCreates a user.",
+		"GET `/v1/users`",
+		"DELETE `/v1/users/:id`",
+		"sample response to created (status: 201 Created)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var collectionsReportFormats = []string{"text", "json"}
+
+var CollectionsReport string
+
+var collectionsCmd = &cobra.Command{
+	Use:   "collections [export.zip]",
+	Short: "List the collections in a file",
+	Long: "List the collections in a file\n\n" +
+		"The file can be a collection, a data dump from Postman's \"Export data\" with many\n" +
+		"collections, or a gzip file or zip archive of either. Collections in the Collection v1\n" +
+		"format, which data dumps use, are converted to Collection v2.1. Each collection's ID and name\n" +
+		"can be used with --collection to choose which collections to convert.",
+	Example: `  pm2md collections export.zip
+  pm2md collections backup.json.gz --report=json
+  pm2md export.zip users.md --collection="Users API"`,
+	Args: cobra.ExactArgs(1),
+	RunE: collectionsRunFunc,
+}
+
+// collectionSummary is a collection's description in the list of collections in a file.
+type collectionSummary struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Endpoints int    `json:"endpoints"`
+}
+
+// collectionsRunFunc lists the collections in a file.
+func collectionsRunFunc(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(collectionsReportFormats, CollectionsReport) {
+		return fmt.Errorf(
+			"unknown report format %q. The report formats are: %s",
+			CollectionsReport, strings.Join(collectionsReportFormats, ", "),
+		)
+	}
+	collections, err := readCollections(args[0])
+	if err != nil {
+		return err
+	}
+	summaries := make([]collectionSummary, len(collections))
+	for i, collection := range collections {
+		summaries[i] = collectionSummary{
+			ID:        collectionID(collection),
+			Name:      collectionName(collection),
+			Endpoints: measureCoverage(collection).Total.Endpoints,
+		}
+	}
+
+	if CollectionsReport == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summaries)
+	}
+	return writeCollectionsText(os.Stdout, summaries)
+}
+
+// writeCollectionsText writes a table of collections.
+func writeCollectionsText(w io.Writer, summaries []collectionSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tENDPOINTS")
+	for _, summary := range summaries {
+		id := summary.ID
+		if len(id) == 0 {
+			id = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", id, summary.Name, summary.Endpoints)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,564 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configFileName = ".pm2md.yaml"
+
+var outputFormats = []string{"markdown", "html"}
+
+// Config is the content of a project configuration file.
+type Config struct {
+	Targets map[string]Target `yaml:"targets"`
+
+	// dir is the folder the configuration file is in. Relative paths in the targets are
+	// relative to this folder.
+	dir string
+}
+
+// Target is a named set of options for generating one output file.
+type Target struct {
+	Input          string           `yaml:"input"`
+	Inputs         []MergeInput     `yaml:"inputs"`
+	Collections    []string         `yaml:"collections"`
+	CollectionID   string           `yaml:"collection_id"`
+	EnvironmentID  string           `yaml:"environment_id"`
+	APIKeyEnv      string           `yaml:"api_key_env"`
+	APIURL         string           `yaml:"api_url"`
+	Title          string           `yaml:"title"`
+	Output         string           `yaml:"output"`
+	Inject         string           `yaml:"inject"`
+	Marker         string           `yaml:"marker"`
+	Template       TemplateList     `yaml:"template"`
+	TemplatePath   []string         `yaml:"template_path"`
+	Statuses       string           `yaml:"statuses"`
+	FolderStatuses []FolderStatuses `yaml:"folder_statuses"`
+	EnvFile        string           `yaml:"env"`
+	Format         string           `yaml:"format"`
+	Strict         bool             `yaml:"strict"`
+	Anchors        string           `yaml:"anchors"`
+	TOC            TOCConfig        `yaml:"toc"`
+	Headings       HeadingsConfig   `yaml:"headings"`
+	Redact         RedactConfig     `yaml:"redact"`
+	Filter         FilterConfig     `yaml:"filter"`
+	Examples       ExamplesConfig   `yaml:"examples"`
+	Sort           SortConfig       `yaml:"sort"`
+	Replace        bool             `yaml:"replace"`
+	KeepExisting   bool             `yaml:"keep_existing"`
+	Check          bool             `yaml:"-"`
+}
+
+// TemplateList is the paths of a target's template files, template folders, and built-in
+// templates. In a config file, it can be one path or a list of paths.
+type TemplateList []string
+
+// UnmarshalYAML decodes one path or a list of paths.
+func (l *TemplateList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = TemplateList{value.Value}
+		return nil
+	}
+	var paths []string
+	if err := value.Decode(&paths); err != nil {
+		return err
+	}
+	*l = paths
+	return nil
+}
+
+// TOCConfig is a target's table of contents settings.
+type TOCConfig struct {
+	Depth   int  `yaml:"depth"`
+	Numbers bool `yaml:"numbers"`
+	Methods bool `yaml:"methods"`
+}
+
+// FolderStatuses is a list of statuses, in the same format as Target.Statuses, to use
+// instead of Target.Statuses for the endpoints in folders matching a glob.
+type FolderStatuses struct {
+	Folder   string `yaml:"folder"`
+	Statuses string `yaml:"statuses"`
+}
+
+// FilterConfig is a target's endpoint filter settings.
+type FilterConfig struct {
+	Include FilterRules `yaml:"include"`
+	Exclude FilterRules `yaml:"exclude"`
+}
+
+// FilterRules are lists of folder path globs, HTTP methods, endpoint name regular
+// expressions, and description tags that match endpoints.
+type FilterRules struct {
+	Folders []string `yaml:"folders"`
+	Methods []string `yaml:"methods"`
+	Names   []string `yaml:"names"`
+	Tags    []string `yaml:"tags"`
+}
+
+// ExamplesConfig is a target's sample response filter settings.
+type ExamplesConfig struct {
+	Names        []string `yaml:"names"`
+	ExcludeNames []string `yaml:"exclude_names"`
+	ContentTypes []string `yaml:"content_types"`
+	Headers      []string `yaml:"headers"`
+	Max          int      `yaml:"max"`
+}
+
+// RedactConfig is a target's redaction settings. Redaction is on if Enabled is true or
+// any other setting is used, and the default rules are always included when it's on.
+type RedactConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Keys     []string `yaml:"keys"`
+	Headers  []string `yaml:"headers"`
+	Patterns []string `yaml:"patterns"`
+	Emails   bool     `yaml:"emails"`
+}
+
+// HeadingsConfig is a target's header level settings.
+type HeadingsConfig struct {
+	Base     int    `yaml:"base"`
+	Overflow string `yaml:"overflow"`
+}
+
+// MergeInput is one of the collections a target merges into one document.
+type MergeInput struct {
+	Path string `yaml:"path"`
+
+	// Prefix is added to the names of the collection's variables to keep them apart
+	// from other collections' variables with the same names.
+	Prefix string `yaml:"prefix"`
+}
+
+// SortConfig is a target's order settings.
+type SortConfig struct {
+	Items    string `yaml:"items"`
+	Folders  string `yaml:"folders"`
+	Examples string `yaml:"examples"`
+}
+
+// findConfig looks for a configuration file in the given folder and then in each of
+// its parent folders, and returns the path to the first one found.
+func findConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		configPath := filepath.Join(dir, configFileName)
+		if FileExists(configPath) {
+			return configPath, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no %s file found in the current folder or any parent folder", configFileName)
+		}
+		dir = parent
+	}
+}
+
+// loadConfig reads and validates the configuration file at the given path.
+func loadConfig(configPath string) (*Config, error) {
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	config, err := parseConfig(configBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", configPath, err)
+	}
+	config.dir = filepath.Dir(configPath)
+	return config, nil
+}
+
+// parseConfig converts a configuration file's YAML content to a Config and validates it.
+// Unknown fields are reported as errors so that typos don't go unnoticed.
+func parseConfig(configBytes []byte) (*Config, error) {
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(configBytes))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	if len(config.Targets) == 0 {
+		return nil, errors.New("no targets defined")
+	}
+	for name, target := range config.Targets {
+		if len(target.Input) == 0 && len(target.Inputs) == 0 && len(target.CollectionID) == 0 {
+			return nil, fmt.Errorf("target %q has no input, inputs, or collection_id", name)
+		}
+		if len(target.Output) == 0 && len(target.Inject) == 0 {
+			return nil, fmt.Errorf("target %q has no output or inject file", name)
+		}
+		if err := target.validate(); err != nil {
+			return nil, fmt.Errorf("target %q: %s", name, err)
+		}
+	}
+
+	return &config, nil
+}
+
+// targetNames returns the names of all the config's targets in alphabetical order.
+func (c *Config) targetNames() []string {
+	names := make([]string, 0, len(c.Targets))
+	for name := range c.Targets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// target returns the target with the given name with all of its relative paths made
+// relative to the current folder instead of the configuration file's folder.
+func (c *Config) target(name string) (Target, error) {
+	target, ok := c.Targets[name]
+	if !ok {
+		return Target{}, fmt.Errorf("unknown target %q. The targets are: %s", name, strings.Join(c.targetNames(), ", "))
+	}
+	target.Input = c.resolvePath(target.Input)
+	inputs := make([]MergeInput, len(target.Inputs))
+	for i, input := range target.Inputs {
+		inputs[i] = MergeInput{c.resolvePath(input.Path), input.Prefix}
+	}
+	target.Inputs = inputs
+	target.Output = c.resolvePath(target.Output)
+	target.Inject = c.resolvePath(target.Inject)
+	tmplPaths := make(TemplateList, len(target.Template))
+	for i, tmplPath := range target.Template {
+		if isGalleryRef(tmplPath) {
+			tmplPaths[i] = tmplPath
+		} else {
+			tmplPaths[i] = c.resolvePath(tmplPath)
+		}
+	}
+	target.Template = tmplPaths
+	searchPath := make([]string, len(target.TemplatePath))
+	for i, dir := range target.TemplatePath {
+		searchPath[i] = c.resolvePath(dir)
+	}
+	target.TemplatePath = searchPath
+	target.EnvFile = c.resolvePath(target.EnvFile)
+	return target, nil
+}
+
+// resolvePath makes a path from the configuration file usable from the current folder.
+// Empty paths, "-", and absolute paths are returned unchanged.
+func (c *Config) resolvePath(path string) string {
+	if len(path) == 0 || path == "-" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.dir, path)
+}
+
+// withOverrides returns a copy of the target with each nonempty field of the given
+// overrides replacing the target's field.
+func (t Target) withOverrides(overrides Target) Target {
+	if len(overrides.Input) > 0 {
+		t.Input = overrides.Input
+		t.Inputs = nil
+		t.CollectionID = ""
+	}
+	if len(overrides.Inputs) > 0 {
+		t.Inputs = overrides.Inputs
+		t.Input = ""
+		t.CollectionID = ""
+	}
+	if len(overrides.CollectionID) > 0 {
+		t.CollectionID = overrides.CollectionID
+		t.Input = ""
+		t.Inputs = nil
+	}
+	if len(overrides.EnvironmentID) > 0 {
+		t.EnvironmentID = overrides.EnvironmentID
+		t.EnvFile = ""
+	}
+	if len(overrides.APIKeyEnv) > 0 {
+		t.APIKeyEnv = overrides.APIKeyEnv
+	}
+	if len(overrides.APIURL) > 0 {
+		t.APIURL = overrides.APIURL
+	}
+	if len(overrides.Collections) > 0 {
+		t.Collections = overrides.Collections
+	}
+	if len(overrides.Title) > 0 {
+		t.Title = overrides.Title
+	}
+	if len(overrides.Output) > 0 {
+		t.Output = overrides.Output
+		t.Inject = ""
+	}
+	if len(overrides.Inject) > 0 {
+		t.Inject = overrides.Inject
+		t.Output = ""
+	}
+	if len(overrides.Marker) > 0 {
+		t.Marker = overrides.Marker
+	}
+	if len(overrides.Template) > 0 {
+		t.Template = overrides.Template
+	}
+	if len(overrides.TemplatePath) > 0 {
+		t.TemplatePath = overrides.TemplatePath
+	}
+	if len(overrides.Statuses) > 0 {
+		t.Statuses = overrides.Statuses
+	}
+	if len(overrides.FolderStatuses) > 0 {
+		t.FolderStatuses = overrides.FolderStatuses
+	}
+	if len(overrides.EnvFile) > 0 {
+		t.EnvFile = overrides.EnvFile
+		t.EnvironmentID = ""
+	}
+	if len(overrides.Format) > 0 {
+		t.Format = overrides.Format
+	}
+	if overrides.Strict {
+		t.Strict = true
+	}
+	if len(overrides.Anchors) > 0 {
+		t.Anchors = overrides.Anchors
+	}
+	if overrides.TOC.Depth != 0 {
+		t.TOC.Depth = overrides.TOC.Depth
+	}
+	if overrides.TOC.Numbers {
+		t.TOC.Numbers = true
+	}
+	if overrides.TOC.Methods {
+		t.TOC.Methods = true
+	}
+	if overrides.Headings.Base != 0 {
+		t.Headings.Base = overrides.Headings.Base
+	}
+	if len(overrides.Headings.Overflow) > 0 {
+		t.Headings.Overflow = overrides.Headings.Overflow
+	}
+	if overrides.Redact.Enabled {
+		t.Redact.Enabled = true
+	}
+	if len(overrides.Redact.Keys) > 0 {
+		t.Redact.Keys = overrides.Redact.Keys
+	}
+	if len(overrides.Redact.Headers) > 0 {
+		t.Redact.Headers = overrides.Redact.Headers
+	}
+	if len(overrides.Redact.Patterns) > 0 {
+		t.Redact.Patterns = overrides.Redact.Patterns
+	}
+	if overrides.Redact.Emails {
+		t.Redact.Emails = true
+	}
+	t.Filter.Include = t.Filter.Include.withOverrides(overrides.Filter.Include)
+	t.Filter.Exclude = t.Filter.Exclude.withOverrides(overrides.Filter.Exclude)
+	if len(overrides.Examples.Names) > 0 {
+		t.Examples.Names = overrides.Examples.Names
+	}
+	if len(overrides.Examples.ExcludeNames) > 0 {
+		t.Examples.ExcludeNames = overrides.Examples.ExcludeNames
+	}
+	if len(overrides.Examples.ContentTypes) > 0 {
+		t.Examples.ContentTypes = overrides.Examples.ContentTypes
+	}
+	if len(overrides.Examples.Headers) > 0 {
+		t.Examples.Headers = overrides.Examples.Headers
+	}
+	if overrides.Examples.Max != 0 {
+		t.Examples.Max = overrides.Examples.Max
+	}
+	if len(overrides.Sort.Items) > 0 {
+		t.Sort.Items = overrides.Sort.Items
+	}
+	if len(overrides.Sort.Folders) > 0 {
+		t.Sort.Folders = overrides.Sort.Folders
+	}
+	if len(overrides.Sort.Examples) > 0 {
+		t.Sort.Examples = overrides.Sort.Examples
+	}
+	if overrides.Replace {
+		t.Replace = true
+	}
+	if overrides.KeepExisting {
+		t.KeepExisting = true
+	}
+	if overrides.Check {
+		t.Check = true
+	}
+	return t
+}
+
+// withOverrides returns a copy of the rules with each nonempty list of the given
+// overrides replacing the rules' list.
+func (r FilterRules) withOverrides(overrides FilterRules) FilterRules {
+	if len(overrides.Folders) > 0 {
+		r.Folders = overrides.Folders
+	}
+	if len(overrides.Methods) > 0 {
+		r.Methods = overrides.Methods
+	}
+	if len(overrides.Names) > 0 {
+		r.Names = overrides.Names
+	}
+	if len(overrides.Tags) > 0 {
+		r.Tags = overrides.Tags
+	}
+	return r
+}
+
+// validate checks the target's fields for values that could never work.
+func (t Target) validate() error {
+	if len(t.Input) > 0 && t.Input != "-" && !isCollectionPath(t.Input) {
+		return fmt.Errorf("%q must be \"-\" or end with \".json\", \".json.gz\", or \".zip\"", t.Input)
+	}
+	if len(t.Input) > 0 && len(t.Inputs) > 0 {
+		return fmt.Errorf("choose either one input or inputs to merge, not both")
+	}
+	for _, input := range t.Inputs {
+		if !isCollectionPath(input.Path) {
+			return fmt.Errorf("%q must end with \".json\", \".json.gz\", or \".zip\"", input.Path)
+		}
+	}
+	if len(t.CollectionID) > 0 && (len(t.Input) > 0 || len(t.Inputs) > 0) {
+		return fmt.Errorf("choose either a collection ID or an input file, not both")
+	}
+	if len(t.EnvironmentID) > 0 && len(t.EnvFile) > 0 {
+		return fmt.Errorf("choose either an environment ID or an environment file, not both")
+	}
+	if len(t.APIURL) > 0 {
+		if u, err := url.Parse(t.APIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid API URL %q. It must start with \"https://\" or \"http://\"", t.APIURL)
+		}
+	}
+	if len(t.Collections) > 0 && len(t.CollectionID) > 0 {
+		return fmt.Errorf("collections can only be chosen from an input file, not with a collection ID")
+	}
+	if len(t.Collections) > 0 && len(t.Inputs) > 0 {
+		return fmt.Errorf("collections can only be chosen from one input, not from inputs to merge")
+	}
+	for _, tmplPath := range t.Template {
+		if isGalleryRef(tmplPath) {
+			if _, _, err := loadGalleryTmpl(tmplPath); err != nil {
+				return err
+			}
+		} else if info, err := os.Stat(tmplPath); err == nil && info.IsDir() {
+			continue
+		} else if len(tmplPath) > 0 && !strings.HasSuffix(tmplPath, ".tmpl") {
+			return fmt.Errorf(
+				"%q must end with \".tmpl\", be a folder, or be a built-in template such as \"@table\"",
+				tmplPath,
+			)
+		}
+	}
+	if len(t.Output) > 0 && len(t.Inject) > 0 {
+		return fmt.Errorf("choose either an output file or a file to inject into, not both")
+	}
+	if len(t.Marker) > 0 && !markerNamePattern.MatchString(t.Marker) {
+		return fmt.Errorf("invalid marker name %q. Marker names can have letters, digits, \"_\", \".\", and \"-\"", t.Marker)
+	}
+	if len(t.Inject) == 0 && (len(t.Marker) > 0 || t.Check) {
+		return fmt.Errorf("a marker name and --check can only be used when injecting into a file")
+	}
+	if len(t.Format) > 0 && !slices.Contains(outputFormats, t.Format) {
+		return fmt.Errorf("unknown format %q. The supported formats are: %s", t.Format, strings.Join(outputFormats, ", "))
+	}
+	if _, err := t.redactRules(); err != nil {
+		return err
+	}
+	_, err := t.renderOptions()
+	return err
+}
+
+// redactRules converts the target's redaction settings to redaction rules. If redaction
+// is off, the rules are nil.
+func (t Target) redactRules() (*redactRules, error) {
+	r := t.Redact
+	if !r.Enabled && !r.Emails && len(r.Keys) == 0 && len(r.Headers) == 0 && len(r.Patterns) == 0 {
+		return nil, nil
+	}
+	return newRedactRules(r.Keys, r.Headers, r.Patterns, r.Emails)
+}
+
+// renderOptions converts the target's values to options for renderText.
+func (t Target) renderOptions() (renderOptions, error) {
+	statuses, err := parseStatusFilter(t.Statuses)
+	if err != nil {
+		return renderOptions{}, err
+	}
+	folderStatuses := make([]folderStatusFilter, len(t.FolderStatuses))
+	for i, fs := range t.FolderStatuses {
+		if _, err := path.Match(fs.Folder, ""); err != nil || len(fs.Folder) == 0 {
+			return renderOptions{}, fmt.Errorf("invalid folder glob %q for statuses %q", fs.Folder, fs.Statuses)
+		}
+		folderStatuses[i].folder = strings.Trim(fs.Folder, "/")
+		folderStatuses[i].statuses, err = parseStatusFilter(fs.Statuses)
+		if err != nil {
+			return renderOptions{}, fmt.Errorf("folder %q: %s", fs.Folder, err)
+		}
+	}
+	if _, err := getAnchorStyle(t.Anchors); err != nil {
+		return renderOptions{}, err
+	}
+	if t.TOC.Depth < 0 {
+		return renderOptions{}, fmt.Errorf("the table of contents depth must not be negative, got %d", t.TOC.Depth)
+	}
+	headings, err := parseHeadingStrategy(t.Headings.Base, t.Headings.Overflow)
+	if err != nil {
+		return renderOptions{}, err
+	}
+	filter, err := newItemFilter(t.Filter.Include, t.Filter.Exclude)
+	if err != nil {
+		return renderOptions{}, err
+	}
+	examples, err := newExampleFilter(t.Examples)
+	if err != nil {
+		return renderOptions{}, err
+	}
+	sort, err := parseSortOptions(t.Sort)
+	if err != nil {
+		return renderOptions{}, err
+	}
+
+	return renderOptions{
+		tmplPaths:      t.Template,
+		tmplSearchPath: t.TemplatePath,
+		statuses:       statuses,
+		folderStatuses: folderStatuses,
+		anchorStyle:    t.Anchors,
+		toc: tocOptions{
+			maxDepth:    t.TOC.Depth,
+			numbered:    t.TOC.Numbers,
+			showMethods: t.TOC.Methods,
+		},
+		headings: headings,
+		filter:   filter,
+		examples: examples,
+		sort:     sort,
+		html:     t.Format == "html",
+		strict:   t.Strict,
+	}, nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleConfig = `
+targets:
+  api-v1:
+    input: ../samples/calendar-API.postman_collection.json
+    output: docs/api-v1.md
+  api-v2:
+    input: collections/api-v2.json
+    output: /tmp/api-v2.md
+    template: custom.tmpl
+    statuses: 200-299
+    folder_statuses:
+      - folder: admin/*
+        statuses: 2xx,!204
+    env: envs/dev.json
+    format: markdown
+    strict: true
+    anchors: gitlab
+    redact:
+      keys: [ssn]
+      emails: true
+    replace: true
+`
+
+func TestParseConfig(t *testing.T) {
+	config, err := parseConfig([]byte(sampleConfig))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := Target{
+		Input:    "collections/api-v2.json",
+		Output:   "/tmp/api-v2.md",
+		Template: TemplateList{"custom.tmpl"},
+		Statuses: "200-299",
+		FolderStatuses: []FolderStatuses{
+			{Folder: "admin/*", Statuses: "2xx,!204"},
+		},
+		EnvFile: "envs/dev.json",
+		Format:  "markdown",
+		Strict:  true,
+		Anchors: "gitlab",
+		Redact:  RedactConfig{Keys: []string{"ssn"}, Emails: true},
+		Replace: true,
+	}
+	if ans := config.Targets["api-v2"]; !reflect.DeepEqual(ans, want) {
+		t.Errorf("parseConfig(...).Targets[\"api-v2\"] = %+v, want %+v", ans, want)
+	}
+	wantNames := []string{"api-v1", "api-v2"}
+	if ans := config.targetNames(); !reflect.DeepEqual(ans, wantNames) {
+		t.Errorf("config.targetNames() = %q, want %q", ans, wantNames)
+	}
+}
+
+func TestParseConfigWithInvalidInput(t *testing.T) {
+	tests := []struct {
+		name, input string
+	}{
+		{"no targets", "targets: {}"},
+		{"unknown field", "targets:\n  a:\n    input: a.json\n    output: a.md\n    tempalte: a.tmpl"},
+		{"no input", "targets:\n  a:\n    output: a.md"},
+		{"no output", "targets:\n  a:\n    input: a.json"},
+		{"invalid input", "targets:\n  a:\n    input: a.txt\n    output: a.md"},
+		{"invalid template", "targets:\n  a:\n    input: a.json\n    output: a.md\n    template: a.txt"},
+		{"invalid format", "targets:\n  a:\n    input: a.json\n    output: a.md\n    format: docx"},
+		{"invalid anchors", "targets:\n  a:\n    input: a.json\n    output: a.md\n    anchors: word"},
+		{"invalid redaction pattern", "targets:\n  a:\n    input: a.json\n    output: a.md\n    redact:\n      patterns: ['(']"},
+		{"invalid folder statuses", "targets:\n  a:\n    input: a.json\n    output: a.md\n    folder_statuses:\n      - folder: admin\n        statuses: 2yy"},
+		{"invalid folder glob", "targets:\n  a:\n    input: a.json\n    output: a.md\n    folder_statuses:\n      - folder: '['\n        statuses: 2xx"},
+		{"invalid statuses", "targets:\n  a:\n    input: a.json\n    output: a.md\n    statuses: a-b"},
+		{"output and inject", "targets:\n  a:\n    input: a.json\n    output: a.md\n    inject: README.md"},
+		{"marker without inject", "targets:\n  a:\n    input: a.json\n    output: a.md\n    marker: users"},
+		{"invalid marker", "targets:\n  a:\n    input: a.json\n    inject: README.md\n    marker: 'a b'"},
+		{"input and inputs", "targets:\n  a:\n    input: a.json\n    inputs:\n      - path: b.json\n    output: a.md"},
+		{"invalid inputs", "targets:\n  a:\n    inputs:\n      - path: b.txt\n    output: a.md"},
+		{"invalid YAML", "targets: ["},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if config, err := parseConfig([]byte(test.input)); err == nil {
+				t.Errorf("parseConfig(%q) = (%v, nil), want non-nil error", test.input, config)
+			}
+		})
+	}
+}
+
+func TestConfigTarget(t *testing.T) {
+	config, err := parseConfig([]byte(sampleConfig))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.dir = "project"
+
+	target, err := config.target("api-v2")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if want := filepath.Join("project", "collections", "api-v2.json"); target.Input != want {
+		t.Errorf("target.Input = %q, want %q", target.Input, want)
+	}
+	if want := "/tmp/api-v2.md"; target.Output != want {
+		t.Errorf("target.Output = %q, want %q", target.Output, want)
+	}
+	if want := filepath.Join("project", "envs", "dev.json"); target.EnvFile != want {
+		t.Errorf("target.EnvFile = %q, want %q", target.EnvFile, want)
+	}
+
+	if _, err := config.target("nonexistent"); err == nil {
+		t.Error("config.target(\"nonexistent\") returned nil error, want non-nil error")
+	}
+}
+
+func TestTargetWithOverrides(t *testing.T) {
+	target := Target{Input: "a.json", Output: "a.md", Statuses: "200", Template: TemplateList{"a.tmpl"}}
+	overrides := Target{Statuses: "400-499", Replace: true}
+	want := Target{Input: "a.json", Output: "a.md", Statuses: "400-499", Template: TemplateList{"a.tmpl"}, Replace: true}
+	if ans := target.withOverrides(overrides); !reflect.DeepEqual(ans, want) {
+		t.Errorf("withOverrides(%+v) = %+v, want %+v", overrides, ans, want)
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	subDir := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(subDir, 0o755); err != nil {
+		t.Error(err)
+		return
+	}
+	wantPath := filepath.Join(root, configFileName)
+	if err := os.WriteFile(wantPath, []byte(sampleConfig), 0o644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	ansPath, err := findConfig(subDir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ansPath != wantPath {
+		t.Errorf("findConfig(%q) = %q, want %q", subDir, ansPath, wantPath)
+	}
+}
+
+func TestGenerateTarget(t *testing.T) {
+	destPath := filepath.Join(t.TempDir(), "calendar.md")
+	target := Target{
+		Input:    "../samples/minimal-calendar-API.postman_collection.json",
+		Output:   destPath,
+		Template: TemplateList{"minimal.tmpl"},
+	}
+	ansPath, err := generateTarget(target)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ansPath != destPath {
+		t.Errorf("generateTarget(...) = %q, want %q", ansPath, destPath)
+	}
+	ansBytes, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	wantBytes, err := os.ReadFile("../samples/minimal-calendar-API-v1.md")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ans := strings.ReplaceAll(string(ansBytes), "\r\n", "\n")
+	want := strings.ReplaceAll(string(wantBytes), "\r\n", "\n")
+	if err := AssertNoDiff(ans, want, "\n"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBuildTargetReplacesOutput(t *testing.T) {
+	inputPath, err := filepath.Abs("../samples/minimal-calendar-API.postman_collection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	configStr := fmt.Sprintf(`
+targets:
+  docs:
+    input: %q
+    output: docs.md
+  kept:
+    input: %q
+    output: kept.md
+    keep_existing: true
+`, inputPath, inputPath)
+	configPath := filepath.Join(dir, configFileName)
+	if err := os.WriteFile(configPath, []byte(configStr), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"docs.md", "kept.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		target, err := buildTarget(config, "docs", Target{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := generateTarget(target); err != nil {
+			t.Errorf("build %d of target \"docs\": %s", i+1, err)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "docs.md")); string(b) == "old" {
+		t.Error("build didn't replace docs.md")
+	}
+
+	target, err := buildTarget(config, "kept", Target{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateTarget(target); err == nil {
+		t.Error("build replaced kept.md, which the target keeps")
+	}
+	target, err = buildTarget(config, "kept", Target{Replace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateTarget(target); err != nil {
+		t.Errorf("build with --replace of target \"kept\": %s", err)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// coverageCounts is how many endpoints in a group have each kind of documentation.
+type coverageCounts struct {
+	Folder    string `json:"folder,omitempty"`
+	Endpoints int    `json:"endpoints"`
+
+	// Descriptions is the number of endpoints with descriptions.
+	Descriptions int `json:"descriptions"`
+
+	// Examples is the number of endpoints with sample responses.
+	Examples int `json:"examples"`
+
+	// ErrorExamples is the number of endpoints with sample responses with 4xx or 5xx
+	// statuses.
+	ErrorExamples int `json:"errorExamples"`
+
+	// Parameters is the number of endpoints whose query parameters, path variables, and
+	// form fields all have descriptions. Endpoints without any parameters are included.
+	Parameters int `json:"parameters"`
+}
+
+// coverageReport is the documentation coverage of a collection as a whole and of each
+// of its folders.
+type coverageReport struct {
+	Total coverageCounts `json:"total"`
+
+	// Root is the counts of the endpoints that aren't in any folder, or nil if there are
+	// none. Its counts and those of the outermost folders add up to the total.
+	Root *coverageCounts `json:"root,omitempty"`
+
+	// Folders has one entry per folder path, such as "admin/users", in the order of the
+	// collection's folders. Each folder's counts include its subfolders' endpoints.
+	Folders []coverageCounts `json:"folders"`
+
+	// Score is the average of the total percentages.
+	Score float64 `json:"score"`
+}
+
+// measureCoverage counts the endpoints in the collection with each kind of
+// documentation.
+func measureCoverage(collection map[string]any) coverageReport {
+	report := coverageReport{Folders: make([]coverageCounts, 0)}
+	items, _ := collection["item"].([]any)
+	report.Total = _measureCoverage(items, "", &report)
+	report.Total.Folder = ""
+	var root coverageCounts
+	for _, itemAny := range items {
+		item := itemAny.(map[string]any)
+		if _, ok := item["item"]; !ok { // if item is an endpoint
+			root.add(measureEndpointCoverage(item))
+		}
+	}
+	if root.Endpoints > 0 {
+		report.Root = &root
+	}
+	report.Score = report.Total.score()
+	return report
+}
+
+func _measureCoverage(items []any, folderPath string, report *coverageReport) coverageCounts {
+	counts := coverageCounts{Folder: folderPath}
+	folderIndex := len(report.Folders)
+	if len(folderPath) > 0 {
+		report.Folders = append(report.Folders, counts)
+	}
+	for _, itemAny := range items {
+		item := itemAny.(map[string]any)
+		if subItemsAny, ok := item["item"]; ok { // if item is a folder
+			subFolderPath := fmt.Sprint(item["name"])
+			if len(folderPath) > 0 {
+				subFolderPath = folderPath + "/" + subFolderPath
+			}
+			counts.add(_measureCoverage(subItemsAny.([]any), subFolderPath, report))
+		} else { // if item is an endpoint
+			counts.add(measureEndpointCoverage(item))
+		}
+	}
+	if len(folderPath) > 0 {
+		report.Folders[folderIndex] = counts
+	}
+	return counts
+}
+
+// measureEndpointCoverage returns the counts of a group with only the given endpoint.
+func measureEndpointCoverage(endpoint map[string]any) coverageCounts {
+	counts := coverageCounts{Endpoints: 1}
+	request, _ := endpoint["request"].(map[string]any)
+	description := descriptionText(endpoint["description"]) + descriptionText(request["description"])
+	if len(strings.TrimSpace(description)) > 0 {
+		counts.Descriptions = 1
+	}
+	responses, _ := endpoint["response"].([]any)
+	if len(responses) > 0 {
+		counts.Examples = 1
+	}
+	for _, responseAny := range responses {
+		response, _ := responseAny.(map[string]any)
+		if code, _ := response["code"].(float64); code >= 400 && code <= 599 {
+			counts.ErrorExamples = 1
+			break
+		}
+	}
+	if parametersDocumented(request) {
+		counts.Parameters = 1
+	}
+	return counts
+}
+
+// parametersDocumented reports whether all of a request's query parameters, path
+// variables, and form fields have descriptions. Disabled parameters are ignored.
+func parametersDocumented(request map[string]any) bool {
+	var params []any
+	if url, ok := request["url"].(map[string]any); ok {
+		query, _ := url["query"].([]any)
+		variables, _ := url["variable"].([]any)
+		params = append(params, query...)
+		params = append(params, variables...)
+	}
+	if body, ok := request["body"].(map[string]any); ok {
+		urlencoded, _ := body["urlencoded"].([]any)
+		formdata, _ := body["formdata"].([]any)
+		params = append(params, urlencoded...)
+		params = append(params, formdata...)
+	}
+	for _, paramAny := range params {
+		param, ok := paramAny.(map[string]any)
+		if !ok || param["disabled"] == true {
+			continue
+		}
+		if len(strings.TrimSpace(descriptionText(param["description"]))) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalJSON converts the counts to JSON with the percentages of the group's endpoints
+// that the counts are. A group without endpoints has no percentages.
+func (c coverageCounts) MarshalJSON() ([]byte, error) {
+	type counts coverageCounts // without this method
+	round := func(percent float64) float64 { return math.Round(percent*10) / 10 }
+	var percents map[string]float64
+	if c.Endpoints > 0 {
+		percents = map[string]float64{
+			"descriptions":  round(c.percent(c.Descriptions)),
+			"examples":      round(c.percent(c.Examples)),
+			"errorExamples": round(c.percent(c.ErrorExamples)),
+			"parameters":    round(c.percent(c.Parameters)),
+		}
+	}
+	return json.Marshal(struct {
+		counts
+		Percent map[string]float64 `json:"percent,omitempty"`
+	}{counts(c), percents})
+}
+
+// add adds another group's counts to the counts.
+func (c *coverageCounts) add(other coverageCounts) {
+	c.Endpoints += other.Endpoints
+	c.Descriptions += other.Descriptions
+	c.Examples += other.Examples
+	c.ErrorExamples += other.ErrorExamples
+	c.Parameters += other.Parameters
+}
+
+// percent returns the percentage of the group's endpoints that the count is. A group
+// without endpoints has nothing undocumented, so it is 100% in the score, but reports
+// show its percentages as n/a.
+func (c coverageCounts) percent(count int) float64 {
+	if c.Endpoints == 0 {
+		return 100
+	}
+	return 100 * float64(count) / float64(c.Endpoints)
+}
+
+// score returns the average of the group's percentages.
+func (c coverageCounts) score() float64 {
+	sum := c.percent(c.Descriptions) + c.percent(c.Examples) + c.percent(c.ErrorExamples) + c.percent(c.Parameters)
+	return math.Round(sum/4*10) / 10
+}
+
+// writeCoverageMarkdown writes the coverage report as a markdown table with a row for
+// all endpoints, a "(root)" row for the endpoints outside of folders if there are any,
+// and a row for each folder. A group without endpoints has n/a percentages.
+func writeCoverageMarkdown(w io.Writer, report coverageReport) {
+	fmt.Fprintf(w, "Documentation coverage: %s\n\n", formatPercent(report.Score))
+	fmt.Fprintln(w, "| folder | endpoints | descriptions | examples | error examples | parameters |")
+	fmt.Fprintln(w, "| --- | ---: | ---: | ---: | ---: | ---: |")
+	writeRow := func(name string, c coverageCounts) {
+		if c.Endpoints == 0 {
+			fmt.Fprintf(w, "| %s | 0 | n/a | n/a | n/a | n/a |\n", name)
+			return
+		}
+		fmt.Fprintf(
+			w, "| %s | %d | %s | %s | %s | %s |\n",
+			name, c.Endpoints,
+			formatPercent(c.percent(c.Descriptions)),
+			formatPercent(c.percent(c.Examples)),
+			formatPercent(c.percent(c.ErrorExamples)),
+			formatPercent(c.percent(c.Parameters)),
+		)
+	}
+	writeRow("**all**", report.Total)
+	if report.Root != nil {
+		writeRow("(root)", *report.Root)
+	}
+	for _, folder := range report.Folders {
+		writeRow(strings.ReplaceAll(folder.Folder, "|", `\|`), folder)
+	}
+}
+
+// formatPercent formats a percentage with at most one decimal place, such as "87.5%".
+func formatPercent(percent float64) string {
+	return fmt.Sprintf("%s%%", strings.TrimSuffix(fmt.Sprintf("%.1f", percent), ".0"))
+}
+
+// coverageBadge returns an SVG badge that shows the given coverage percentage, colored
+// from red to green.
+func coverageBadge(score float64) string {
+	const label = "docs coverage"
+	value := formatPercent(score)
+	var color string
+	switch {
+	case score >= 90:
+		color = "#4c1"
+	case score >= 75:
+		color = "#97ca00"
+	case score >= 60:
+		color = "#dfb317"
+	case score >= 40:
+		color = "#fe7d37"
+	default:
+		color = "#e05d44"
+	}
+	// Each character is about 7 pixels wide in 11px Verdana.
+	labelWidth := 7*len(label) + 10
+	valueWidth := 7*len(value) + 10
+	width := labelWidth + valueWidth
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+  <title>%[4]s: %[5]s</title>
+  <linearGradient id="s" x2="0" y2="100%%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[2]d" height="20" fill="#555"/>
+    <rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/>
+    <rect width="%[1]d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text>
+    <text x="%[7]d" y="14">%[4]s</text>
+    <text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text>
+    <text x="%[8]d" y="14">%[5]s</text>
+  </g>
+</svg>
+`, width, labelWidth, valueWidth, label, value, color, labelWidth/2, labelWidth+valueWidth/2)
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var coverageReportFormats = []string{"markdown", "json"}
+
+var CoverageReport string
+var CoverageBadgePath string
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage [collection.json]",
+	Short: "Measure how well a collection's endpoints are documented",
+	Long: "Measure how well a collection's endpoints are documented\n\n" +
+		"For all endpoints and for each folder, the report shows the percentage of endpoints\n" +
+		"with descriptions, with sample responses, with error (4xx or 5xx) sample responses,\n" +
+		"and whose query parameters, path variables, and form fields all have descriptions.\n" +
+		"The coverage score is the average of the four percentages for all endpoints.",
+	Example: `  pm2md coverage collection.json
+  pm2md coverage collection.json --report=json
+  pm2md coverage collection.json --badge=docs/coverage.svg`,
+	Args: cobra.ExactArgs(1),
+	RunE: coverageRunFunc,
+}
+
+// coverageRunFunc measures a collection's documentation coverage, prints a report, and
+// saves a badge if chosen.
+func coverageRunFunc(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(coverageReportFormats, CoverageReport) {
+		return fmt.Errorf(
+			"unknown report format %q. The report formats are: %s",
+			CoverageReport, strings.Join(coverageReportFormats, ", "),
+		)
+	}
+	collection, err := readSelectedCollection(args[0], Collections, "")
+	if err != nil {
+		return err
+	}
+	report := measureCoverage(collection)
+
+	if CoverageReport == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		writeCoverageMarkdown(os.Stdout, report)
+	}
+
+	if len(CoverageBadgePath) > 0 {
+		if err := writeFileAtomic(CoverageBadgePath, []byte(coverageBadge(report.Score))); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Created %q\n", CoverageBadgePath)
+	}
+
+	return nil
+}
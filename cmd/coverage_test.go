@@ -0,0 +1,177 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newCoverageTestCollection creates a collection with endpoints with different amounts
+// of documentation.
+func newCoverageTestCollection() map[string]any {
+	return map[string]any{
+		"info": map[string]any{"name": "API"},
+		"item": []any{
+			map[string]any{
+				"name": "users",
+				"item": []any{
+					map[string]any{
+						"name": "get user",
+						"request": map[string]any{
+							"description": "Gets a user.",
+							"url": map[string]any{
+								"query": []any{
+									map[string]any{"key": "fields", "description": "The fields to get."},
+									map[string]any{"key": "debug", "disabled": true},
+								},
+								"variable": []any{map[string]any{"key": "id", "description": "The user's ID."}},
+							},
+						},
+						"response": []any{
+							map[string]any{"code": float64(200)},
+							map[string]any{"code": float64(404)},
+						},
+					},
+					map[string]any{
+						"name": "admin",
+						"item": []any{
+							map[string]any{
+								"name": "create user",
+								"request": map[string]any{
+									"body": map[string]any{
+										"formdata": []any{map[string]any{"key": "name"}},
+									},
+								},
+								"response": []any{map[string]any{"code": float64(201)}},
+							},
+						},
+					},
+				},
+			},
+			map[string]any{"name": "empty", "item": []any{}},
+			map[string]any{
+				"name":        "health",
+				"description": "Checks the service's health.",
+				"request":     map[string]any{"url": "/health"},
+				"response":    []any{},
+			},
+		},
+	}
+}
+
+func TestMeasureCoverage(t *testing.T) {
+	report := measureCoverage(newCoverageTestCollection())
+	want := coverageReport{
+		Total: coverageCounts{Endpoints: 3, Descriptions: 2, Examples: 2, ErrorExamples: 1, Parameters: 2},
+		Root:  &coverageCounts{Endpoints: 1, Descriptions: 1, Examples: 0, ErrorExamples: 0, Parameters: 1},
+		Folders: []coverageCounts{
+			{Folder: "users", Endpoints: 2, Descriptions: 1, Examples: 2, ErrorExamples: 1, Parameters: 1},
+			{Folder: "users/admin", Endpoints: 1, Descriptions: 0, Examples: 1, ErrorExamples: 0, Parameters: 0},
+			{Folder: "empty"},
+		},
+		Score: 58.3,
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("measureCoverage(...) = %+v, want %+v", report, want)
+	}
+}
+
+func TestWriteCoverageMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	writeCoverageMarkdown(&b, measureCoverage(newCoverageTestCollection()))
+	want := "Documentation coverage: 58.3%\n\n" +
+		"| folder | endpoints | descriptions | examples | error examples | parameters |\n" +
+		"| --- | ---: | ---: | ---: | ---: | ---: |\n" +
+		"| **all** | 3 | 66.7% | 66.7% | 33.3% | 66.7% |\n" +
+		"| (root) | 1 | 100% | 0% | 0% | 100% |\n" +
+		"| users | 2 | 50% | 100% | 50% | 50% |\n" +
+		"| users/admin | 1 | 0% | 100% | 0% | 0% |\n" +
+		"| empty | 0 | n/a | n/a | n/a | n/a |\n"
+	if b.String() != want {
+		t.Errorf("writeCoverageMarkdown(...) wrote\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestCoverageCountsJSON(t *testing.T) {
+	tests := []struct {
+		counts coverageCounts
+		want   string
+	}{
+		{
+			coverageCounts{Folder: "users", Endpoints: 2, Descriptions: 1, Examples: 2, ErrorExamples: 1, Parameters: 1},
+			`{"folder":"users","endpoints":2,"descriptions":1,"examples":2,"errorExamples":1,"parameters":1,` +
+				`"percent":{"descriptions":50,"errorExamples":50,"examples":100,"parameters":50}}`,
+		},
+		{
+			coverageCounts{Folder: "empty"},
+			`{"folder":"empty","endpoints":0,"descriptions":0,"examples":0,"errorExamples":0,"parameters":0}`,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := json.Marshal(test.counts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("json.Marshal(%+v) = %s, want %s", test.counts, got, test.want)
+		}
+	}
+}
+
+func TestFormatPercent(t *testing.T) {
+	tests := []struct {
+		percent float64
+		want    string
+	}{
+		{100, "100%"},
+		{0, "0%"},
+		{66.666, "66.7%"},
+		{87.5, "87.5%"},
+	}
+
+	for _, test := range tests {
+		if ans := formatPercent(test.percent); ans != test.want {
+			t.Errorf("formatPercent(%v) = %q, want %q", test.percent, ans, test.want)
+		}
+	}
+}
+
+func TestCoverageBadge(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  []string
+	}{
+		{95, []string{">95%<", `fill="#4c1"`}},
+		{58.3, []string{">58.3%<", `fill="#fe7d37"`}},
+		{10, []string{">10%<", `fill="#e05d44"`}},
+	}
+
+	for _, test := range tests {
+		badge := coverageBadge(test.score)
+		if !strings.HasPrefix(badge, "<svg ") {
+			t.Errorf("coverageBadge(%v) doesn't start with an svg tag", test.score)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(badge, want) {
+				t.Errorf("coverageBadge(%v) doesn't contain %q", test.score, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+var variablePattern = regexp.MustCompile(`{{([^{}]+)}}`)
+
+// loadEnvironment reads a Postman environment JSON file and returns its enabled
+// variables as a map from each variable's name to its value.
+func loadEnvironment(envPath string) (map[string]string, error) {
+	envBytes, err := os.ReadFile(envPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseEnvironment(envBytes)
+}
+
+// parseEnvironment converts a Postman environment from a slice of bytes of JSON to a
+// map from each enabled variable's name to its value.
+func parseEnvironment(envBytes []byte) (map[string]string, error) {
+	var env struct {
+		Values []struct {
+			Key     string `json:"key"`
+			Value   any    `json:"value"`
+			Enabled *bool  `json:"enabled"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(envBytes, &env); err != nil {
+		return nil, fmt.Errorf("invalid environment file: %s", err)
+	}
+	if env.Values == nil {
+		return nil, fmt.Errorf("invalid environment file: no \"values\" list found. When exporting from Postman, export an environment")
+	}
+
+	vars := make(map[string]string, len(env.Values))
+	for _, v := range env.Values {
+		if v.Enabled != nil && !*v.Enabled {
+			continue
+		}
+		if v.Value == nil {
+			vars[v.Key] = ""
+		} else {
+			vars[v.Key] = fmt.Sprint(v.Value)
+		}
+	}
+
+	return vars, nil
+}
+
+// applyEnvironment replaces each `{{name}}` in the collection's strings with the value
+// of the variable of the same name. Variables not in the given map are left unchanged.
+func applyEnvironment(collection map[string]any, vars map[string]string) {
+	if len(vars) == 0 {
+		return
+	}
+	for key, value := range collection {
+		collection[key] = replaceVariables(value, vars)
+	}
+}
+
+func replaceVariables(value any, vars map[string]string) any {
+	switch v := value.(type) {
+	case string:
+		return variablePattern.ReplaceAllStringFunc(v, func(match string) string {
+			if varValue, ok := vars[match[2:len(match)-2]]; ok {
+				return varValue
+			}
+			return match
+		})
+	case map[string]any:
+		for key, subValue := range v {
+			v[key] = replaceVariables(subValue, vars)
+		}
+	case []any:
+		for i, subValue := range v {
+			v[i] = replaceVariables(subValue, vars)
+		}
+	}
+	return value
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnvironment(t *testing.T) {
+	envJson := []byte(`{
+		"name": "dev",
+		"values": [
+			{"key": "base_url", "value": "http://localhost:3000", "enabled": true},
+			{"key": "token", "value": "abc", "enabled": false},
+			{"key": "version", "value": "v1"}
+		]
+	}`)
+	want := map[string]string{"base_url": "http://localhost:3000", "version": "v1"}
+
+	ans, err := parseEnvironment(envJson)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(ans, want) {
+		t.Errorf("parseEnvironment(...) = %v, want %v", ans, want)
+	}
+}
+
+func TestParseEnvironmentWithInvalidInput(t *testing.T) {
+	inputs := []string{"", "{", `{"name": "not an environment"}`}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			if vars, err := parseEnvironment([]byte(input)); err == nil {
+				t.Errorf("parseEnvironment(%q) = (%v, nil), want non-nil error", input, vars)
+			}
+		})
+	}
+}
+
+func TestApplyEnvironment(t *testing.T) {
+	collection := map[string]any{
+		"item": []any{
+			map[string]any{
+				"name": "{{version}} endpoint",
+				"request": map[string]any{
+					"url": map[string]any{
+						"raw":  "{{base_url}}/{{version}}/{{unknown}}",
+						"host": []any{"{{base_url}}"},
+					},
+				},
+			},
+		},
+	}
+	vars := map[string]string{"base_url": "http://localhost:3000", "version": "v1"}
+
+	applyEnvironment(collection, vars)
+
+	item := collection["item"].([]any)[0].(map[string]any)
+	if ans, want := item["name"], "v1 endpoint"; ans != want {
+		t.Errorf("name = %q, want %q", ans, want)
+	}
+	url := item["request"].(map[string]any)["url"].(map[string]any)
+	if ans, want := url["raw"], "http://localhost:3000/v1/{{unknown}}"; ans != want {
+		t.Errorf("raw = %q, want %q", ans, want)
+	}
+	if ans, want := url["host"].([]any)[0], "http://localhost:3000"; ans != want {
+		t.Errorf("host = %q, want %q", ans, want)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// exampleFilter chooses which sample responses to keep by their names, content types,
+// and headers, and limits how many each endpoint keeps.
+type exampleFilter struct {
+	// names are regular expressions. If any are given, a sample response is kept only if
+	// its name matches at least one of them.
+	names []*regexp.Regexp
+
+	// excludeNames are regular expressions. A sample response whose name matches any of
+	// them is removed.
+	excludeNames []*regexp.Regexp
+
+	// contentTypes are lowercase content types such as "json" or "application/json". If
+	// any are given, a sample response is kept only if its preview language equals one of
+	// them or its Content-Type header contains one of them.
+	contentTypes []string
+
+	// headers are lowercase header names. A sample response is kept only if it has all of
+	// them.
+	headers []string
+
+	// max is the most sample responses each endpoint keeps. If zero, there is no limit.
+	max int
+}
+
+// newExampleFilter creates an example filter from the given settings. If there are no
+// settings, the filter is nil.
+func newExampleFilter(config ExamplesConfig) (*exampleFilter, error) {
+	if len(config.Names) == 0 && len(config.ExcludeNames) == 0 && len(config.ContentTypes) == 0 &&
+		len(config.Headers) == 0 && config.Max == 0 {
+		return nil, nil
+	}
+	if config.Max < 0 {
+		return nil, fmt.Errorf("the maximum number of examples must not be negative, got %d", config.Max)
+	}
+	filter := exampleFilter{max: config.Max}
+	var err error
+	if filter.names, err = compileRegexps(config.Names, "example name"); err != nil {
+		return nil, err
+	}
+	if filter.excludeNames, err = compileRegexps(config.ExcludeNames, "example name"); err != nil {
+		return nil, err
+	}
+	for _, contentType := range config.ContentTypes {
+		filter.contentTypes = append(filter.contentTypes, strings.ToLower(contentType))
+	}
+	for _, header := range config.Headers {
+		filter.headers = append(filter.headers, strings.ToLower(header))
+	}
+
+	return &filter, nil
+}
+
+// compileRegexps compiles each of the given regular expressions. The description is used
+// in error messages.
+func compileRegexps(patterns []string, description string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %s", description, pattern, err)
+		}
+		result[i] = re
+	}
+	return result, nil
+}
+
+// filterExamples removes all sample responses the example filter doesn't keep, and then
+// removes each endpoint's sample responses after its first few if there is a maximum. If
+// the filter is nil, the collection remains unchanged.
+func filterExamples(collection map[string]any, filter *exampleFilter) {
+	if filter == nil {
+		return
+	}
+	items := collection["item"].([]any)
+	_filterExamples(items, filter)
+}
+
+func _filterExamples(items []any, filter *exampleFilter) {
+	for _, itemAny := range items {
+		item := itemAny.(map[string]any)
+		if subItemsAny, ok := item["item"]; ok { // if item is a folder
+			_filterExamples(subItemsAny.([]any), filter)
+		} else { // if item is an endpoint
+			responses := item["response"].([]any)
+			kept := make([]any, 0, len(responses))
+			for _, responseAny := range responses {
+				if filter.keeps(responseAny.(map[string]any)) {
+					kept = append(kept, responseAny)
+				}
+			}
+			if filter.max > 0 && len(kept) > filter.max {
+				kept = kept[:filter.max]
+			}
+			item["response"] = kept
+		}
+	}
+}
+
+// keeps reports whether the filter keeps the given sample response, not counting the
+// maximum number of sample responses.
+func (f *exampleFilter) keeps(response map[string]any) bool {
+	name := fmt.Sprint(response["name"])
+	if len(f.names) > 0 && !matchesAnyRegexp(f.names, name) {
+		return false
+	}
+	if matchesAnyRegexp(f.excludeNames, name) {
+		return false
+	}
+
+	headers := make(map[string]string)
+	if headerList, ok := response["header"].([]any); ok {
+		for _, headerAny := range headerList {
+			if header, ok := headerAny.(map[string]any); ok {
+				headers[strings.ToLower(fmt.Sprint(header["key"]))] = fmt.Sprint(header["value"])
+			}
+		}
+	}
+	for _, header := range f.headers {
+		if _, ok := headers[header]; !ok {
+			return false
+		}
+	}
+
+	if len(f.contentTypes) == 0 {
+		return true
+	}
+	previewLanguage, _ := response["_postman_previewlanguage"].(string)
+	contentType := strings.ToLower(headers["content-type"])
+	for _, wanted := range f.contentTypes {
+		if strings.ToLower(previewLanguage) == wanted || strings.Contains(contentType, wanted) {
+			return true
+		}
+	}
+	return false
+}
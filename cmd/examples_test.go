@@ -0,0 +1,160 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newExamplesTestCollection creates a collection with an endpoint that has several
+// sample responses for testing example filters.
+func newExamplesTestCollection() map[string]any {
+	response := func(name, previewLanguage string, headers ...string) map[string]any {
+		headerList := make([]any, 0)
+		for i := 0; i+1 < len(headers); i += 2 {
+			headerList = append(headerList, map[string]any{"key": headers[i], "value": headers[i+1]})
+		}
+		return map[string]any{
+			"name":                     name,
+			"code":                     float64(200),
+			"_postman_previewlanguage": previewLanguage,
+			"header":                   headerList,
+		}
+	}
+	return map[string]any{
+		"info": map[string]any{"name": "API"},
+		"item": []any{
+			map[string]any{
+				"name": "events",
+				"item": []any{
+					map[string]any{
+						"name":    "list events",
+						"request": map[string]any{"method": "GET"},
+						"response": []any{
+							response("success", "json", "Content-Type", "application/json", "X-Request-Id", "1"),
+							response("success as CSV", "text", "Content-Type", "text/csv"),
+							response("draft success", "json"),
+							response("not found", "html", "Content-Type", "text/html; charset=utf-8", "x-request-id", "2"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// exampleNames returns the names of the sample responses of the test collection's
+// endpoint.
+func exampleNames(collection map[string]any) []string {
+	folder := collection["item"].([]any)[0].(map[string]any)
+	endpoint := folder["item"].([]any)[0].(map[string]any)
+	names := make([]string, 0)
+	for _, responseAny := range endpoint["response"].([]any) {
+		names = append(names, responseAny.(map[string]any)["name"].(string))
+	}
+	return names
+}
+
+func TestFilterExamples(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ExamplesConfig
+		want   []string
+	}{
+		{
+			"no filter",
+			ExamplesConfig{},
+			[]string{"success", "success as CSV", "draft success", "not found"},
+		},
+		{
+			"names",
+			ExamplesConfig{Names: []string{"^success"}},
+			[]string{"success", "success as CSV"},
+		},
+		{
+			"exclude names",
+			ExamplesConfig{ExcludeNames: []string{"draft", "CSV"}},
+			[]string{"success", "not found"},
+		},
+		{
+			"preview language",
+			ExamplesConfig{ContentTypes: []string{"JSON"}},
+			[]string{"success", "draft success"},
+		},
+		{
+			"content type header",
+			ExamplesConfig{ContentTypes: []string{"text/csv", "text/html"}},
+			[]string{"success as CSV", "not found"},
+		},
+		{
+			"headers",
+			ExamplesConfig{Headers: []string{"X-Request-ID"}},
+			[]string{"success", "not found"},
+		},
+		{
+			"max",
+			ExamplesConfig{Max: 2},
+			[]string{"success", "success as CSV"},
+		},
+		{
+			"max after other filters",
+			ExamplesConfig{ExcludeNames: []string{"^success$"}, Max: 2},
+			[]string{"success as CSV", "draft success"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := newExampleFilter(test.config)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			collection := newExamplesTestCollection()
+			filterExamples(collection, filter)
+			ans := exampleNames(collection)
+			if !reflect.DeepEqual(ans, test.want) {
+				t.Errorf("got examples %q, want %q", ans, test.want)
+			}
+		})
+	}
+}
+
+func TestNewExampleFilterWithNoSettings(t *testing.T) {
+	filter, err := newExampleFilter(ExamplesConfig{})
+	if filter != nil || err != nil {
+		t.Errorf("newExampleFilter with no settings = (%v, %v), want (nil, nil)", filter, err)
+	}
+}
+
+func TestNewExampleFilterWithInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ExamplesConfig
+	}{
+		{"name", ExamplesConfig{Names: []string{"("}}},
+		{"exclude name", ExamplesConfig{ExcludeNames: []string{"["}}},
+		{"max", ExamplesConfig{Max: -1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := newExampleFilter(test.config); err == nil {
+				t.Errorf("newExampleFilter(%v) returned nil error, want non-nil error", test.config)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// maxSampleLen is the most characters of a field's sample value that are shown.
+const maxSampleLen = 60
+
+// templateField describes one field of the data that templates receive. The fields of
+// all the elements of a list are combined, so ".item[].name" describes the name of each
+// outermost item.
+type templateField struct {
+	// Path is the field's path from the collection, such as ".info.name". "[]" stands for
+	// each element of a list, which templates can get with `range`.
+	Path string `json:"path"`
+
+	// Types is the JSON types the field has: string, number, bool, null, object, or list.
+	Types []string `json:"types"`
+
+	// Count is how many times the field appears.
+	Count int `json:"count"`
+
+	// Sample is the field's first value that isn't empty, if the field isn't an object or
+	// a list. Long text is shortened.
+	Sample any `json:"sample,omitempty"`
+}
+
+// listFields lists all the fields in the data, sorted by path.
+func listFields(data any) []templateField {
+	fields := make(map[string]*templateField)
+	addFields(fields, "", data)
+
+	result := make([]templateField, 0, len(fields))
+	for _, field := range fields {
+		result = append(result, *field)
+	}
+	slices.SortFunc(result, func(a, b templateField) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return result
+}
+
+// addFields adds a value at the given path and everything in it to the fields. The
+// value at the empty path, which is the collection itself, isn't added.
+func addFields(fields map[string]*templateField, path string, value any) {
+	if len(path) > 0 {
+		field, ok := fields[path]
+		if !ok {
+			field = &templateField{Path: path}
+			fields[path] = field
+		}
+		field.Count++
+		if t := jsonTypeName(value); !slices.Contains(field.Types, t) {
+			field.Types = append(field.Types, t)
+		}
+		if field.Sample == nil && !isEmpty(value) {
+			switch v := value.(type) {
+			case map[string]any, []any:
+			case string:
+				field.Sample = shortenText(v, maxSampleLen)
+			default:
+				field.Sample = v
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			addFields(fields, path+"."+key, v[key])
+		}
+	case []any:
+		for _, elem := range v {
+			addFields(fields, path+"[]", elem)
+		}
+	}
+}
+
+// jsonTypeName returns the name of a decoded JSON value's type.
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64, int:
+		return "number"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "list"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// shortenText shortens text to at most the given number of characters, ending it with
+// "..." if it's cut.
+func shortenText(s string, maxLen int) string {
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	return string([]rune(s)[:maxLen-3]) + "..."
+}
+
+// writeFieldsText writes a table of the fields.
+func writeFieldsText(w io.Writer, fields []templateField) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tTYPE\tCOUNT\tSAMPLE")
+	for _, field := range fields {
+		sample := ""
+		if field.Sample != nil {
+			b, err := json.Marshal(field.Sample)
+			if err != nil {
+				return err
+			}
+			sample = string(b)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", field.Path, strings.Join(field.Types, "|"), field.Count, sample)
+	}
+	return tw.Flush()
+}
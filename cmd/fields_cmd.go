@@ -0,0 +1,82 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var fieldsReportFormats = []string{"text", "json", "data"}
+
+var FieldsReport string
+
+var fieldsCmd = &cobra.Command{
+	Use:   "fields collection.json",
+	Short: "List the fields that templates can use",
+	Long: "List the fields that templates can use\n\n" +
+		"The fields are those of the data templates receive, after the collection is\n" +
+		"filtered and sorted and properties such as \"level\" and \"toc\" are added, so flags\n" +
+		"such as --statuses and --env change them the same way they change the output.\n" +
+		"Each field's path, such as .item[].request.method, shows how to get it in a\n" +
+		"template; [] stands for each element of a list, which templates can get with range.\n\n" +
+		"The report formats are text, a table of the fields with their types, how many times\n" +
+		"they appear, and sample values; json, the same list as JSON; and data, the whole\n" +
+		"data tree as JSON.",
+	Example: `  pm2md fields collection.json
+  pm2md fields collection.json --report=json
+  pm2md fields collection.json --statuses=2xx --report=data`,
+	Args: cobra.ExactArgs(1),
+	RunE: fieldsRunFunc,
+}
+
+// fieldsRunFunc prints the fields of the data that templates receive.
+func fieldsRunFunc(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(fieldsReportFormats, FieldsReport) {
+		return fmt.Errorf(
+			"unknown report format %q. The report formats are: %s",
+			FieldsReport, strings.Join(fieldsReportFormats, ", "),
+		)
+	}
+	target := flagTarget().withOverrides(Target{Input: args[0]})
+	opts, err := target.renderOptions()
+	if err != nil {
+		return err
+	}
+	style, err := getAnchorStyle(opts.anchorStyle)
+	if err != nil {
+		return err
+	}
+	collection, err := loadCollection(target)
+	if err != nil {
+		return err
+	}
+	prepareCollection(collection, style, opts)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	switch FieldsReport {
+	case "json":
+		return encoder.Encode(listFields(collection))
+	case "data":
+		return encoder.Encode(collection)
+	}
+	return writeFieldsText(os.Stdout, listFields(collection))
+}
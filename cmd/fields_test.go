@@ -0,0 +1,100 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestListFields(t *testing.T) {
+	data := map[string]any{
+		"info": map[string]any{"name": "api", "description": ""},
+		"item": []any{
+			map[string]any{"name": "folder", "item": []any{}},
+			map[string]any{"name": "", "code": 200.0, "description": map[string]any{"content": "x"}},
+			map[string]any{"name": "endpoint", "code": nil, "description": strings.Repeat("a", 100)},
+		},
+	}
+	want := []templateField{
+		{Path: ".info", Types: []string{"object"}, Count: 1},
+		{Path: ".info.description", Types: []string{"string"}, Count: 1},
+		{Path: ".info.name", Types: []string{"string"}, Count: 1, Sample: "api"},
+		{Path: ".item", Types: []string{"list"}, Count: 1},
+		{Path: ".item[]", Types: []string{"object"}, Count: 3},
+		{Path: ".item[].code", Types: []string{"number", "null"}, Count: 2, Sample: 200.0},
+		{Path: ".item[].description", Types: []string{"object", "string"}, Count: 2, Sample: strings.Repeat("a", maxSampleLen-3) + "..."},
+		{Path: ".item[].description.content", Types: []string{"string"}, Count: 1, Sample: "x"},
+		{Path: ".item[].item", Types: []string{"list"}, Count: 1},
+		{Path: ".item[].name", Types: []string{"string"}, Count: 3, Sample: "folder"},
+	}
+
+	got := listFields(data)
+	if len(got) != len(want) {
+		t.Fatalf("listFields returned %d fields, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range got {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("field %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestListFieldsOfPreparedCollection(t *testing.T) {
+	collection, err := readCollection("../samples/calendar-API.postman_collection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := parseStatusFilter("2xx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepareCollection(collection, anchorStyles[defaultAnchorStyle], renderOptions{statuses: statuses})
+
+	fields := make(map[string]templateField)
+	for _, field := range listFields(collection) {
+		fields[field.Path] = field
+	}
+	for _, path := range []string{".item[].level", ".item[].item[].response[].level", ".toc[].link", ".toc[].indent"} {
+		if _, ok := fields[path]; !ok {
+			t.Errorf("listFields doesn't list %q, which templates receive", path)
+		}
+	}
+	code, ok := fields[".item[].item[].response[].code"]
+	if !ok {
+		t.Fatal("listFields doesn't list the response codes")
+	}
+	if code.Sample != 201.0 {
+		t.Errorf("response code sample = %v, want 201", code.Sample)
+	}
+}
+
+func TestWriteFieldsText(t *testing.T) {
+	var b strings.Builder
+	err := writeFieldsText(&b, []templateField{
+		{Path: ".info", Types: []string{"object"}, Count: 1},
+		{Path: ".info.name", Types: []string{"string", "null"}, Count: 2, Sample: "a \"quoted\" name"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "FIELD       TYPE         COUNT  SAMPLE\n" +
+		".info       object       1      \n" +
+		".info.name  string|null  2      \"a \\\"quoted\\\" name\"\n"
+	if got := b.String(); got != want {
+		t.Errorf("writeFieldsText wrote\n%s\nwant\n%s", got, want)
+	}
+}
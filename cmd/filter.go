@@ -0,0 +1,216 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var tagPattern = regexp.MustCompile(`(?:^|\s)@([\w-]+)`)
+
+// filterRules are rules for matching endpoints. An empty list of rules matches nothing.
+type filterRules struct {
+	// folders are globs that match folder paths such as "admin/users". A glob matches an
+	// endpoint if it matches the path of any folder the endpoint is in.
+	folders []string
+
+	// methods are uppercase HTTP methods.
+	methods map[string]bool
+
+	// names are regular expressions that match endpoint names.
+	names []*regexp.Regexp
+
+	// tags are the names of tags without "@". An endpoint has a tag if its description or
+	// the description of a folder it is in contains the tag, such as "@internal".
+	tags map[string]bool
+}
+
+// itemFilter chooses which endpoints to keep. An endpoint is kept if, for each kind of
+// include rule that has any rules, it matches at least one of those rules, and it
+// matches none of the exclude rules.
+type itemFilter struct {
+	include filterRules
+	exclude filterRules
+}
+
+// newItemFilter creates a filter from lists of folder path globs, HTTP methods, name
+// regular expressions, and tags. If all the lists are empty, the filter is nil.
+func newItemFilter(include, exclude FilterRules) (*itemFilter, error) {
+	if include.isEmpty() && exclude.isEmpty() {
+		return nil, nil
+	}
+	includeRules, err := parseFilterRules(include)
+	if err != nil {
+		return nil, err
+	}
+	excludeRules, err := parseFilterRules(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &itemFilter{include: includeRules, exclude: excludeRules}, nil
+}
+
+func parseFilterRules(rules FilterRules) (filterRules, error) {
+	result := filterRules{
+		methods: make(map[string]bool),
+		tags:    make(map[string]bool),
+	}
+	for _, folder := range rules.Folders {
+		if _, err := path.Match(folder, ""); err != nil {
+			return filterRules{}, fmt.Errorf("invalid folder glob %q: %s", folder, err)
+		}
+		result.folders = append(result.folders, strings.Trim(folder, "/"))
+	}
+	for _, method := range rules.Methods {
+		result.methods[strings.ToUpper(method)] = true
+	}
+	for _, name := range rules.Names {
+		re, err := regexp.Compile(name)
+		if err != nil {
+			return filterRules{}, fmt.Errorf("invalid name pattern %q: %s", name, err)
+		}
+		result.names = append(result.names, re)
+	}
+	for _, tag := range rules.Tags {
+		result.tags[strings.TrimPrefix(tag, "@")] = true
+	}
+	return result, nil
+}
+
+// isEmpty reports whether there are no rules.
+func (r FilterRules) isEmpty() bool {
+	return len(r.Folders) == 0 && len(r.Methods) == 0 && len(r.Names) == 0 && len(r.Tags) == 0
+}
+
+// filterItems removes the endpoints the filter doesn't keep, and then removes all
+// folders that are empty. If the filter is nil, the collection remains unchanged.
+func filterItems(collection map[string]any, filter *itemFilter) {
+	if filter == nil {
+		return
+	}
+	items := collection["item"].([]any)
+	collection["item"] = _filterItems(items, nil, nil, filter)
+}
+
+func _filterItems(items []any, folderNames []string, folderTags []string, filter *itemFilter) []any {
+	kept := make([]any, 0, len(items))
+	for _, itemAny := range items {
+		item := itemAny.(map[string]any)
+		tags := append(folderTags[:len(folderTags):len(folderTags)], descriptionTags(item)...)
+		if subItemsAny, ok := item["item"]; ok { // if item is a folder
+			names := append(folderNames[:len(folderNames):len(folderNames)], fmt.Sprint(item["name"]))
+			subItems := _filterItems(subItemsAny.([]any), names, tags, filter)
+			if len(subItems) > 0 {
+				item["item"] = subItems
+				kept = append(kept, item)
+			}
+		} else if filter.keeps(item, folderNames, tags) { // if item is an endpoint
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// keeps reports whether the filter keeps an endpoint that is in the given folders and
+// has the given tags.
+func (f *itemFilter) keeps(endpoint map[string]any, folderNames []string, tags []string) bool {
+	method, _ := methodAndPath(endpoint)
+	name := fmt.Sprint(endpoint["name"])
+	folderPaths := make([]string, len(folderNames))
+	for i := range folderNames {
+		folderPaths[i] = strings.Join(folderNames[:i+1], "/")
+	}
+
+	inc := f.include
+	if len(inc.folders) > 0 && !matchesAnyGlob(inc.folders, folderPaths) {
+		return false
+	}
+	if len(inc.methods) > 0 && !inc.methods[strings.ToUpper(method)] {
+		return false
+	}
+	if len(inc.names) > 0 && !matchesAnyRegexp(inc.names, name) {
+		return false
+	}
+	if len(inc.tags) > 0 && !containsAnyTag(inc.tags, tags) {
+		return false
+	}
+
+	exc := f.exclude
+	return !matchesAnyGlob(exc.folders, folderPaths) &&
+		!exc.methods[strings.ToUpper(method)] &&
+		!matchesAnyRegexp(exc.names, name) &&
+		!containsAnyTag(exc.tags, tags)
+}
+
+// descriptionTags returns the tags in an item's description and, for an endpoint, in
+// its request's description.
+func descriptionTags(item map[string]any) []string {
+	text := descriptionText(item["description"])
+	if request, ok := item["request"].(map[string]any); ok {
+		text += "\n" + descriptionText(request["description"])
+	}
+	matches := tagPattern.FindAllStringSubmatch(text, -1)
+	tags := make([]string, len(matches))
+	for i, match := range matches {
+		tags[i] = match[1]
+	}
+	return tags
+}
+
+// descriptionText returns a description's text. Postman descriptions are usually
+// strings but can be objects with a "content" property.
+func descriptionText(description any) string {
+	switch d := description.(type) {
+	case string:
+		return d
+	case map[string]any:
+		if content, ok := d["content"].(string); ok {
+			return content
+		}
+	}
+	return ""
+}
+
+func matchesAnyGlob(globs []string, folderPaths []string) bool {
+	for _, glob := range globs {
+		for _, folderPath := range folderPaths {
+			if matched, _ := path.Match(glob, folderPath); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesAnyRegexp(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAnyTag(wanted map[string]bool, tags []string) bool {
+	for _, tag := range tags {
+		if wanted[tag] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newFilterTestCollection creates a collection with folders and endpoints for testing
+// filters.
+func newFilterTestCollection() map[string]any {
+	endpoint := func(name, method, description string) map[string]any {
+		return map[string]any{
+			"name": name,
+			"request": map[string]any{
+				"method":      method,
+				"description": description,
+				"url":         map[string]any{"path": []any{"v1", name}},
+			},
+			"response": []any{},
+		}
+	}
+	return map[string]any{
+		"info": map[string]any{"name": "API"},
+		"item": []any{
+			map[string]any{
+				"name":        "admin",
+				"description": "Admin endpoints. @internal",
+				"item": []any{
+					endpoint("list users", "GET", ""),
+					map[string]any{
+						"name": "audit",
+						"item": []any{endpoint("list events", "GET", "")},
+					},
+				},
+			},
+			map[string]any{
+				"name": "public",
+				"item": []any{
+					endpoint("list events", "GET", "@beta"),
+					endpoint("create event", "POST", ""),
+					endpoint("delete event", "DELETE", "Deletes an event. @internal"),
+				},
+			},
+			endpoint("health", "GET", ""),
+		},
+	}
+}
+
+// endpointPaths returns the paths of the endpoints in the given items, such as
+// "public/create event".
+func endpointPaths(items []any, prefix string) []string {
+	paths := make([]string, 0)
+	for _, itemAny := range items {
+		item := itemAny.(map[string]any)
+		name := item["name"].(string)
+		if subItemsAny, ok := item["item"]; ok {
+			paths = append(paths, endpointPaths(subItemsAny.([]any), prefix+name+"/")...)
+		} else {
+			paths = append(paths, prefix+name)
+		}
+	}
+	return paths
+}
+
+func TestFilterItems(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude FilterRules
+		want             []string
+	}{
+		{
+			"exclude tag",
+			FilterRules{},
+			FilterRules{Tags: []string{"@internal"}},
+			[]string{"public/list events", "public/create event", "health"},
+		},
+		{
+			"include tag",
+			FilterRules{Tags: []string{"beta"}},
+			FilterRules{},
+			[]string{"public/list events"},
+		},
+		{
+			"include folder",
+			FilterRules{Folders: []string{"admin"}},
+			FilterRules{},
+			[]string{"admin/list users", "admin/audit/list events"},
+		},
+		{
+			"folder glob",
+			FilterRules{Folders: []string{"*/audit"}},
+			FilterRules{},
+			[]string{"admin/audit/list events"},
+		},
+		{
+			"methods",
+			FilterRules{Methods: []string{"get", "post"}},
+			FilterRules{Folders: []string{"admin"}},
+			[]string{"public/list events", "public/create event", "health"},
+		},
+		{
+			"names",
+			FilterRules{Names: []string{"^list"}},
+			FilterRules{Methods: []string{"DELETE"}, Names: []string{"users"}},
+			[]string{"admin/audit/list events", "public/list events"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := newItemFilter(test.include, test.exclude)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			collection := newFilterTestCollection()
+			filterItems(collection, filter)
+			ans := endpointPaths(collection["item"].([]any), "")
+			if !reflect.DeepEqual(ans, test.want) {
+				t.Errorf("got endpoints %q, want %q", ans, test.want)
+			}
+		})
+	}
+}
+
+func TestFilterItemsPrunesEmptyFolders(t *testing.T) {
+	filter, err := newItemFilter(FilterRules{Names: []string{"health"}}, FilterRules{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	collection := newFilterTestCollection()
+	filterItems(collection, filter)
+	items := collection["item"].([]any)
+	if len(items) != 1 || items[0].(map[string]any)["name"] != "health" {
+		t.Errorf("got items %v, want only the \"health\" endpoint", items)
+	}
+}
+
+func TestNewItemFilterWithNoRules(t *testing.T) {
+	filter, err := newItemFilter(FilterRules{}, FilterRules{})
+	if filter != nil || err != nil {
+		t.Errorf("newItemFilter with no rules = (%v, %v), want (nil, nil)", filter, err)
+	}
+}
+
+func TestNewItemFilterWithInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules FilterRules
+	}{
+		{"folder", FilterRules{Folders: []string{"["}}},
+		{"name", FilterRules{Names: []string{"("}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := newItemFilter(test.rules, FilterRules{}); err == nil {
+				t.Errorf("newItemFilter(%v, ...) returned nil error, want non-nil error", test.rules)
+			}
+		})
+	}
+}
@@ -15,51 +15,94 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"html/template"
-	"slices"
+	htmltemplate "html/template"
 	"strings"
+	"text/template"
 )
 
-var funcMap = template.FuncMap{
-	"formatHeaderLink": formatHeaderLink,
-	"add": func(a, b int) int {
-		return a + b
-	},
-	"join": func(elems []any, sep string) string {
-		strElems := make([]string, len(elems))
-		for i, e := range elems {
-			strElems[i] = fmt.Sprint(e)
-		}
-		return strings.Join(strElems, sep)
-	},
-	"allowJsonOrPlaintext": func(s string) any {
-		if json.Valid([]byte(s)) {
-			return template.HTML(s)
-		}
-		return s
-	},
-	// "assumeSafeHtml": func(s string) template.HTML {
-	// 	// This prevents HTML escaping. Never run this with untrusted input.
-	// 	return template.HTML(s)
-	// },
+// newFuncMap returns the functions available in templates. Some of the functions keep
+// state about what they have output so far, so each render needs its own FuncMap and
+// its own headerLinker. The general-purpose functions are in template_funcs.go.
+func newFuncMap(headerLinks *headerLinker, headings headingStrategy) template.FuncMap {
+	funcMap := template.FuncMap{
+		"formatHeaderLink": headerLinks.formatHeaderLink,
+		"headingTag":       headings.headingTag,
+		"heading":          headings.markdownHeading,
+		"headingElement":   headings.htmlHeading,
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"join": func(elems []any, sep string) string {
+			strElems := make([]string, len(elems))
+			for i, e := range elems {
+				strElems[i] = fmt.Sprint(e)
+			}
+			return strings.Join(strElems, sep)
+		},
+		// allowJsonOrPlaintext returns the text as it is. It's kept so that existing
+		// templates keep working. Values are never escaped in markdown output and are
+		// always escaped in HTML output, so use the escaping functions instead.
+		"allowJsonOrPlaintext": func(s string) string {
+			return s
+		},
+	}
+	for name, f := range libraryFuncs() {
+		funcMap[name] = f
+	}
+	return funcMap
+}
+
+// newHTMLFuncMap is like newFuncMap, but the functions that output markup escape the
+// text they are given and return the result as htmltemplate.HTML so that html/template
+// doesn't escape the markup itself.
+func newHTMLFuncMap(headerLinks *headerLinker, headings headingStrategy) template.FuncMap {
+	funcMap := newFuncMap(headerLinks, headings)
+	funcMap["formatHeaderLink"] = func(headerBody string) htmltemplate.HTML {
+		return htmltemplate.HTML(fmt.Sprintf(
+			"[%s](%s)",
+			htmltemplate.HTMLEscapeString(headerBody),
+			htmltemplate.HTMLEscapeString(headerLinks.headerPath(headerBody)),
+		))
+	}
+	funcMap["heading"] = func(level int, text string) htmltemplate.HTML {
+		return htmltemplate.HTML(headings.markdownHeading(level, htmltemplate.HTMLEscapeString(text)))
+	}
+	funcMap["headingElement"] = func(level int, text string) htmltemplate.HTML {
+		return htmltemplate.HTML(headings.htmlHeading(level, htmltemplate.HTMLEscapeString(text)))
+	}
+	return funcMap
 }
 
-var headerPathCache = make([]string, 0, 10)
+// headerLinker creates links to the headers of one render's output. It remembers the
+// link paths it has created so that duplicate headers get unique links.
+type headerLinker struct {
+	style anchorStyle
+	paths map[string]bool
+}
+
+// newHeaderLinker creates a headerLinker that formats links in the given style.
+func newHeaderLinker(style anchorStyle) *headerLinker {
+	return &headerLinker{style: style, paths: make(map[string]bool)}
+}
 
 // formatHeaderLink formats a markdown header body as a markdown link to the header
-// compatible with GitHub's markdown rendering. When GitHub and this function find
+// compatible with the linker's markdown renderer. When GitHub and this function find
 // duplicate headers, they append `-1` to the header link for the second occurence, `-2`
-// for the third, and so on.
-func formatHeaderLink(headerBody string) string {
-	headerPath := formatHeaderPath(headerBody)
+// for the third, and so on. Some other renderers use a different separator.
+func (h *headerLinker) formatHeaderLink(headerBody string) string {
+	return fmt.Sprintf("[%s](%s)", headerBody, h.headerPath(headerBody))
+}
+
+// headerPath returns a unique relative link path for a header body.
+func (h *headerLinker) headerPath(headerBody string) string {
+	headerPath := h.style.formatPath(headerBody)
 	uniqueHeaderPath := headerPath
-	for i := 1; slices.Contains(headerPathCache, uniqueHeaderPath); i++ {
-		uniqueHeaderPath = fmt.Sprintf("%s-%d", headerPath, i)
+	for i := 1; h.paths[uniqueHeaderPath]; i++ {
+		uniqueHeaderPath = fmt.Sprintf("%s%s%d", headerPath, h.style.duplicateSep, i)
 	}
-	headerPathCache = append(headerPathCache, uniqueHeaderPath)
-	return fmt.Sprintf("[%s](%s)", headerBody, uniqueHeaderPath)
+	h.paths[uniqueHeaderPath] = true
+	return uniqueHeaderPath
 }
 
 // formatHeaderPath formats a markdown header body as a relative link path compatible
@@ -30,10 +30,11 @@ func TestFormatHeaderLink(t *testing.T) {
 		{"sample request body", "[sample request body](#sample-request-body-2)"},
 	}
 
+	headerLinks := newHeaderLinker(anchorStyles[defaultAnchorStyle])
 	for i, test := range tests {
 		name := fmt.Sprintf("(%d) %q", i, test.input)
 		t.Run(name, func(t *testing.T) {
-			ans := formatHeaderLink(test.input)
+			ans := headerLinks.formatHeaderLink(test.input)
 			if ans != test.want {
 				t.Errorf("formatHeaderLink(%q) = %q, want %q", test.input, ans, test.want)
 			}
@@ -0,0 +1,87 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed templates/*.tmpl
+var galleryFS embed.FS
+
+// galleryTemplate is a built-in template that can be chosen by name, such as with
+// `--template=@table`.
+type galleryTemplate struct {
+	name        string
+	description string
+
+	// file is the template's file in the templates folder. The default and minimal
+	// templates aren't in the templates folder, so they have no file.
+	file string
+}
+
+// gallery is the built-in templates in the order they are listed.
+var gallery = []galleryTemplate{
+	{"default", "Collapsible sections for each folder and endpoint with sample requests and responses", ""},
+	{"minimal", "Headers, methods, paths, sample requests, and sample responses, without folders", ""},
+	{"table", "A table of each folder's endpoints with their methods, paths, and descriptions", "table.tmpl"},
+	{"compact", "Each endpoint's method, path, description, and response statuses, without bodies", "compact.tmpl"},
+	{"api-reference", "A reference with tables of parameters and headers, request bodies, and responses", "api-reference.tmpl"},
+	{"gitbook", "GitBook markdown with hints, code blocks, and a tab for each sample response", "gitbook.tmpl"},
+	{"confluence", "Confluence wiki markup with a table of contents and collapsible code blocks", "confluence.tmpl"},
+}
+
+// galleryTemplateNames returns the names of the built-in templates.
+func galleryTemplateNames() []string {
+	names := make([]string, len(gallery))
+	for i, t := range gallery {
+		names[i] = t.name
+	}
+	return names
+}
+
+// isGalleryRef reports whether a template path refers to a built-in template, such as
+// "@table".
+func isGalleryRef(tmplPath string) bool {
+	return strings.HasPrefix(tmplPath, "@")
+}
+
+// loadGalleryTmpl returns the file name and the text of the built-in template with the
+// given name. The name may start with "@".
+func loadGalleryTmpl(name string) (string, string, error) {
+	name = strings.TrimPrefix(name, "@")
+	for _, t := range gallery {
+		if t.name != name {
+			continue
+		}
+		switch t.name {
+		case "default":
+			return defaultTmplName, defaultTmplStr, nil
+		case "minimal":
+			return "minimal.tmpl", minimalTmplStr, nil
+		}
+		tmplBytes, err := galleryFS.ReadFile("templates/" + t.file)
+		if err != nil {
+			return "", "", err
+		}
+		return t.file, string(tmplBytes), nil
+	}
+	return "", "", fmt.Errorf(
+		"unknown built-in template %q. The built-in templates are: %s",
+		name, strings.Join(galleryTemplateNames(), ", "),
+	)
+}
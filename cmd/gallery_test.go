@@ -0,0 +1,228 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadGalleryTmpl(t *testing.T) {
+	tests := []struct {
+		tmplPath, wantName, wantStr string
+	}{
+		{"@default", defaultTmplName, defaultTmplStr},
+		{"@minimal", "minimal.tmpl", minimalTmplStr},
+		{"table", "table.tmpl", ""},
+		{"@api-reference", "api-reference.tmpl", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tmplPath, func(t *testing.T) {
+			tmplName, tmplStr, err := loadGalleryTmpl(test.tmplPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tmplName != test.wantName {
+				t.Errorf("template name = %q, want %q", tmplName, test.wantName)
+			}
+			if len(test.wantStr) > 0 && tmplStr != test.wantStr {
+				t.Errorf("template %q isn't the embedded template", test.tmplPath)
+			}
+			if len(tmplStr) == 0 {
+				t.Errorf("template %q is empty", test.tmplPath)
+			}
+		})
+	}
+}
+
+func TestLoadGalleryTmplUnknown(t *testing.T) {
+	_, _, err := loadTmpl("@nonexistent")
+	if err == nil || !strings.Contains(err.Error(), "default, minimal, table") {
+		t.Errorf("loadTmpl(\"@nonexistent\") error = %v, want an error listing the built-in templates", err)
+	}
+}
+
+func TestGalleryTemplatesRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantParts []string
+	}{
+		{"default", []string{"# calendar API", "<details open>"}},
+		{"minimal", []string{"# calendar API", "## edit account"}},
+		{"table", []string{"| POST | `/v1/account/register` | create account | Users can create an account with this endpoint. |"}},
+		{"compact", []string{"`POST /v1/account/register`", "* 201 Created - valid input"}},
+		{"api-reference", []string{"* POST endpoints\n  * create account\n", "```http\nPOST /v1/account/register\n```", "### request body", "#### 201 Created"}},
+		{"gitbook", []string{"{% hint style=\"info\" %}", "{% tab title=\"201 Created\" %}"}},
+		{"confluence", []string{"h1. calendar API", "{{POST /v1/account/register}}", "{code:title=sample request body|language=json}"}},
+	}
+	if len(tests) != len(gallery) {
+		t.Errorf("%d templates tested, want all %d built-in templates", len(tests), len(gallery))
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jsonPath := "../samples/calendar-API.postman_collection.json"
+			if test.name == "minimal" {
+				// The minimal template is for collections without folders.
+				jsonPath = "../samples/minimal-calendar-API.postman_collection.json"
+			}
+			collection, err := readCollection(jsonPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			if err := renderText(collection, &b, renderOptions{tmplPaths: []string{"@" + test.name}}); err != nil {
+				t.Fatal(err)
+			}
+			got := b.String()
+			if strings.Contains(got, "<no value>") {
+				t.Errorf("@%s output contains \"<no value>\":\n%s", test.name, got)
+			}
+			for _, want := range test.wantParts {
+				if !strings.Contains(got, want) {
+					t.Errorf("@%s output doesn't contain %q:\n%s", test.name, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateGalleryTemplate(t *testing.T) {
+	if err := (Target{Template: TemplateList{"@table"}}).validate(); err != nil {
+		t.Errorf("validate with template \"@table\" = %v, want nil", err)
+	}
+	if err := (Target{Template: TemplateList{"@tables"}}).validate(); err == nil {
+		t.Error("validate with template \"@tables\" = nil, want non-nil error")
+	}
+}
+
+func TestTemplatesGet(t *testing.T) {
+	destPath := filepath.Join(t.TempDir(), "docs.tmpl")
+	if err := templatesGetRunFunc(nil, []string{"@compact", destPath}); err != nil {
+		t.Fatal(err)
+	}
+	_, want, err := loadGalleryTmpl("compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("templates get saved a different template than @compact")
+	}
+
+	if err := templatesGetRunFunc(nil, []string{"compact", destPath}); err == nil {
+		t.Error("templates get replaced an existing file without --replace")
+	}
+}
+
+func TestTableTemplateEscapesCells(t *testing.T) {
+	collection := map[string]any{
+		"info": map[string]any{"name": "api"},
+		"item": []any{
+			map[string]any{
+				"name": "read | write",
+				"request": map[string]any{
+					"method":      "GET",
+					"url":         map[string]any{"path": []any{"files"}},
+					"description": "Reads or\nwrites a file.",
+				},
+				"response": []any{},
+			},
+		},
+	}
+	var b strings.Builder
+	if err := renderText(collection, &b, renderOptions{tmplPaths: []string{"@table"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "| GET | `/files` | read \\| write | Reads or<br>writes a file. |"
+	if got := b.String(); !strings.Contains(got, want) {
+		t.Errorf("@table output doesn't contain %q:\n%s", want, got)
+	}
+}
+
+func TestGalleryTemplatesRenderStrict(t *testing.T) {
+	samples := []string{
+		"../samples/calendar-API.postman_collection.json",
+		"../samples/minimal-calendar-API.postman_collection.json",
+	}
+	for _, name := range galleryTemplateNames() {
+		for _, jsonPath := range samples {
+			if name == "minimal" && !strings.Contains(jsonPath, "minimal") {
+				// The minimal template is for collections without folders.
+				continue
+			}
+			t.Run(name+"/"+filepath.Base(jsonPath), func(t *testing.T) {
+				render := func(strict bool) string {
+					collection, err := readCollection(jsonPath)
+					if err != nil {
+						t.Fatal(err)
+					}
+					var b strings.Builder
+					opts := renderOptions{tmplPaths: []string{"@" + name}, strict: strict}
+					if err := renderText(collection, &b, opts); err != nil {
+						t.Fatalf("@%s with strict = %v: %s", name, strict, err)
+					}
+					return b.String()
+				}
+				if got, want := render(true), render(false); got != want {
+					t.Errorf("@%s output with strict:\n%s\nwant the same output as without strict:\n%s", name, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestGalleryTemplatesRenderStrictWithoutOptionalFields(t *testing.T) {
+	// Endpoints and responses only need the fields the collection schema requires.
+	collection := func() map[string]any {
+		return map[string]any{
+			"info": map[string]any{"name": "api"},
+			"item": []any{
+				map[string]any{
+					"name":    "read file",
+					"request": map[string]any{"url": map[string]any{"path": []any{"files"}}},
+					"response": []any{
+						map[string]any{"name": "found", "code": float64(200), "status": "OK"},
+					},
+				},
+			},
+		}
+	}
+	for _, name := range galleryTemplateNames() {
+		t.Run(name, func(t *testing.T) {
+			render := func(strict bool) string {
+				var b strings.Builder
+				opts := renderOptions{tmplPaths: []string{"@" + name}, strict: strict}
+				if err := renderText(collection(), &b, opts); err != nil {
+					t.Fatalf("@%s with strict = %v: %s", name, strict, err)
+				}
+				return b.String()
+			}
+			got, want := render(true), render(false)
+			if got != want {
+				t.Errorf("@%s output with strict:\n%s\nwant the same output as without strict:\n%s", name, got, want)
+			}
+			if strings.Contains(got, "<no value>") {
+				t.Errorf("@%s output contains \"<no value>\":\n%s", name, got)
+			}
+		})
+	}
+}
@@ -15,110 +15,292 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"math"
 	"slices"
 	"strconv"
 	"strings"
 	"text/template"
 )
 
-// generateText converts a collection to plaintext and saves it into the given open file
-// without closing the file. `Seek(0, 0)` is then called on the file so the file pointer
-// is at the beginning of the file unless an error occurs. If the given template path is
-// empty, the default template is used. If any status ranges are given, responses with
-// statuses outside those ranges are removed from the collection. A `level` integer
-// property is added to each "item" and each "response" object within the collection.
-// The level starts at 1 for the outermost item object and increases by 1 for each level
-// of item nesting.
-func generateText(collection map[string]any, openAnsFile *os.File, tmplPath string, statusRanges [][]int) error {
-	filterResponsesByStatus(collection, statusRanges)
-	addLevelProperty(collection)
+// renderOptions are the settings for converting a collection to plaintext.
+type renderOptions struct {
+	// tmplPaths are the paths of the template files, template folders, and built-in
+	// templates to use. If empty, the default template is used. See loadTmplSet.
+	tmplPaths []string
+
+	// tmplSearchPath is the folders of partials that templates can use.
+	tmplSearchPath []string
+
+	// statuses chooses which sample responses to keep. If nil, all sample responses are
+	// kept.
+	statuses *statusFilter
+
+	// folderStatuses choose which sample responses to keep in matching folders instead
+	// of statuses.
+	folderStatuses []folderStatusFilter
+
+	// anchorStyle is the name of the style of header links. If empty, the default style
+	// is used.
+	anchorStyle string
+
+	// toc is the settings for the table of contents.
+	toc tocOptions
+
+	// headings converts levels to header levels.
+	headings headingStrategy
 
-	tmplName, tmplStr, err := loadTmpl(tmplPath)
+	// html executes the template with html/template instead of text/template so that
+	// values are escaped for HTML.
+	html bool
+
+	// strict makes using a field that the data doesn't have an error instead of
+	// outputting "<no value>" or nothing.
+	strict bool
+
+	// filter chooses which endpoints to keep. If nil, all endpoints are kept.
+	filter *itemFilter
+
+	// examples chooses which sample responses to keep by properties other than their
+	// statuses. If nil, no sample responses are removed by it.
+	examples *exampleFilter
+
+	// sort is the orders of endpoints, folders, and sample responses.
+	sort sortOptions
+}
+
+// renderText converts a collection to plaintext and writes it to the given writer. The
+// collection is first changed by prepareCollection. If no template paths are given, the
+// default template is used.
+func renderText(collection map[string]any, w io.Writer, opts renderOptions) error {
+	style, err := getAnchorStyle(opts.anchorStyle)
 	if err != nil {
 		return err
 	}
+	prepareCollection(collection, style, opts)
 
-	return executeTmpl(collection, openAnsFile, tmplName, tmplStr)
+	set, err := loadTmplSet(opts.tmplPaths, opts.tmplSearchPath)
+	if err != nil {
+		return err
+	}
+
+	var tmplOptions []string
+	if opts.strict {
+		tmplOptions = append(tmplOptions, "missingkey=error")
+	}
+	if opts.html {
+		funcMap := newHTMLFuncMap(newHeaderLinker(style), opts.headings)
+		return executeHTMLTmplSet(collection, w, set, funcMap, tmplOptions)
+	}
+	funcMap := newFuncMap(newHeaderLinker(style), opts.headings)
+	return executeTmplSet(collection, w, set, funcMap, tmplOptions)
+}
+
+// prepareCollection changes a collection into the data that templates receive. If a
+// filter is given, endpoints it doesn't keep and then empty folders are removed. If any
+// status filters are given, responses with statuses the filters don't keep are removed
+// from the collection. The remaining endpoints, folders, and responses are sorted as
+// chosen, and then responses the example filter doesn't keep are removed, so that the
+// maximum number of responses keeps the first ones in the sorted order. A `level` integer
+// property is added to each "item" and each "response" object within the collection.
+// The level starts at 1 for the outermost item object and increases by 1 for each level
+// of item nesting. A "toc" property with a table of contents is added to the collection.
+func prepareCollection(collection map[string]any, style anchorStyle, opts renderOptions) {
+	filterItems(collection, opts.filter)
+	filterResponsesByStatus(collection, opts.statuses, opts.folderStatuses)
+	sortCollection(collection, opts.sort)
+	filterExamples(collection, opts.examples)
+	addLevelProperty(collection)
+	addTableOfContents(collection, style, opts.toc, opts.headings)
 }
 
 // parseCollection converts a collection from a slice of bytes of JSON to a map.
 func parseCollection(jsonBytes []byte) (map[string]any, error) {
+	return decodeCollection(bytes.NewReader(jsonBytes))
+}
+
+// decodeCollection converts a collection from a stream of JSON to a map. The JSON is
+// decoded as it is read, so it doesn't have to be read into memory first, and there is
+// no limit on the length of its lines. Strings are kept exactly as they are, including
+// their line endings.
+func decodeCollection(r io.Reader) (map[string]any, error) {
 	var collection map[string]any
-	if err := json.Unmarshal(jsonBytes, &collection); err != nil {
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&collection); err != nil {
 		return nil, err
 	}
-	if collection["info"].(map[string]any)["schema"] != "https://schema.getpostman.com/json/collection/v2.1.0/collection.json" {
-		return nil, fmt.Errorf("unknown JSON schema. When exporting from Postman, export as Collection v2.1")
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: unexpected data after the collection")
+	}
+	if err := checkCollection(collection); err != nil {
+		return nil, err
 	}
 
 	return collection, nil
 }
 
-// parseStatusRanges converts a string of status ranges to a slice of slices of
-// integers. The slice may be nil, but any inner slices each have two elements: the
-// start and end of the range. Example inputs: "200", "200-299", "200-299,400-499",
-// "200-200".
-func parseStatusRanges(statusesStr string) ([][]int, error) {
-	if len(statusesStr) == 0 {
+// checkCollection returns an error if a decoded collection isn't a Collection v2.1.
+func checkCollection(collection map[string]any) error {
+	info, ok := collection["info"].(map[string]any)
+	if !ok {
+		return fmt.Errorf("no collection info found. When exporting from Postman, export as Collection v2.1")
+	}
+	if info["schema"] != collectionSchema {
+		return fmt.Errorf("unknown JSON schema. When exporting from Postman, export as Collection v2.1")
+	}
+	return nil
+}
+
+// readCollection reads a collection from a file, or from stdin if the path is "-", and
+// converts it to a map. The file can be any file readCollections can read, but it must
+// have exactly one collection.
+func readCollection(jsonPath string) (map[string]any, error) {
+	return readSelectedCollection(jsonPath, nil, "")
+}
+
+// statusFilter chooses which sample responses to keep by their status codes. Each range
+// has two elements: the start and end of the range, inclusive.
+type statusFilter struct {
+	// include are the ranges of statuses to keep. If empty, all statuses that aren't
+	// excluded are kept.
+	include [][]int
+
+	// exclude are the ranges of statuses to remove.
+	exclude [][]int
+}
+
+// folderStatusFilter is a status filter for the endpoints in matching folders.
+type folderStatusFilter struct {
+	// folder is a glob that matches folder paths such as "admin/users".
+	folder   string
+	statuses *statusFilter
+}
+
+// parseStatusFilter converts a comma-separated list of statuses to a status filter.
+// Each item is a status such as "200", a class such as "2xx", a range such as
+// "200-299", or an open-ended range such as "500-". An item starting with "!" excludes
+// statuses instead of including them. Example inputs: "200", "200-299,400-499",
+// "2xx,!204", "400-,!404". If the given string is empty, the filter is nil. An error
+// names the first invalid item and its position in the string.
+func parseStatusFilter(statusesStr string) (*statusFilter, error) {
+	if len(strings.TrimSpace(statusesStr)) == 0 {
 		return nil, nil
 	}
-	statusRangeStrs := strings.Split(statusesStr, ",")
-	statusRanges := make([][]int, len(statusRangeStrs))
-	for i, statusRangeStr := range statusRangeStrs {
-		startAndEnd := strings.Split(statusRangeStr, "-")
-		if len(startAndEnd) > 2 {
-			return nil, fmt.Errorf("invalid status format. There should be zero or one dashes in %s", statusRangeStr)
-		}
-		start, err := strconv.Atoi(startAndEnd[0])
+	var filter statusFilter
+	position := 1
+	for _, token := range strings.Split(statusesStr, ",") {
+		trimmed := strings.TrimSpace(token)
+		statusRange, err := parseStatusToken(strings.TrimPrefix(trimmed, "!"))
 		if err != nil {
-			return nil, fmt.Errorf("invalid status range format. Expected an integer, got %q", startAndEnd[0])
+			tokenPosition := position + strings.Index(token, trimmed)
+			return nil, fmt.Errorf(
+				"invalid status %q at position %d of %q: %s",
+				trimmed, tokenPosition, statusesStr, err,
+			)
 		}
-		end := start
-		if len(startAndEnd) > 1 {
-			end, err = strconv.Atoi(startAndEnd[1])
-			if err != nil {
-				return nil, fmt.Errorf("invalid status range format. Expected an integer, got %q", startAndEnd[1])
-			}
+		if strings.HasPrefix(trimmed, "!") {
+			filter.exclude = append(filter.exclude, statusRange)
+		} else {
+			filter.include = append(filter.include, statusRange)
 		}
-		statusRanges[i] = make([]int, 2)
-		statusRanges[i][0] = start
-		statusRanges[i][1] = end
+		position += len(token) + 1
+	}
+
+	return &filter, nil
+}
+
+// parseStatusToken converts a status, status class, or status range without any "!" to
+// the start and end of a range.
+func parseStatusToken(token string) ([]int, error) {
+	const help = "expected a status such as 404, a class such as 4xx, or a range such as 200-299 or 500-"
+	if len(token) == 0 {
+		return nil, errors.New(help)
+	}
+	if len(token) == 3 && strings.HasSuffix(strings.ToLower(token), "xx") {
+		class, err := strconv.Atoi(token[:1])
+		if err != nil || class < 1 {
+			return nil, fmt.Errorf("the class must start with a digit from 1 to 9. %s", help)
+		}
+		return []int{class * 100, class*100 + 99}, nil
+	}
+
+	startStr, endStr, isRange := strings.Cut(token, "-")
+	start, err := strconv.Atoi(startStr)
+	if err != nil || start < 0 {
+		return nil, fmt.Errorf("%q is not a status. %s", startStr, help)
 	}
+	if !isRange {
+		return []int{start, start}, nil
+	}
+	if len(endStr) == 0 {
+		return []int{start, math.MaxInt}, nil
+	}
+	end, err := strconv.Atoi(endStr)
+	if err != nil || end < 0 {
+		return nil, fmt.Errorf("%q is not a status. %s", endStr, help)
+	}
+	if end < start {
+		return nil, fmt.Errorf("the range's start must not be greater than its end")
+	}
+	return []int{start, end}, nil
+}
 
-	return statusRanges, nil
+// keeps reports whether the filter keeps responses with the given status code. A nil
+// filter keeps all responses.
+func (f *statusFilter) keeps(code int) bool {
+	if f == nil {
+		return true
+	}
+	inRanges := func(ranges [][]int) bool {
+		for _, statusRange := range ranges {
+			if code >= statusRange[0] && code <= statusRange[1] {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(f.include) == 0 || inRanges(f.include)) && !inRanges(f.exclude)
 }
 
-// filterResponsesByStatus removes all sample responses with status codes outside the
-// given range(s). If no status ranges are given, the collection remains unchanged.
-func filterResponsesByStatus(collection map[string]any, statusRanges [][]int) {
-	if len(statusRanges) == 0 {
+// filterResponsesByStatus removes all sample responses with status codes the status
+// filter doesn't keep. For endpoints in folders matched by a folder status filter, the
+// first matching folder status filter is used instead. If no filters are given, the
+// collection remains unchanged.
+func filterResponsesByStatus(collection map[string]any, statuses *statusFilter, folderStatuses []folderStatusFilter) {
+	if statuses == nil && len(folderStatuses) == 0 {
 		return
 	}
 	items := collection["item"].([]any)
-	_filterResponsesByStatus(items, statusRanges)
+	_filterResponsesByStatus(items, nil, statuses, folderStatuses)
 }
 
-func _filterResponsesByStatus(items []any, statusRanges [][]int) {
+func _filterResponsesByStatus(items []any, folderPaths []string, statuses *statusFilter, folderStatuses []folderStatusFilter) {
 	for _, itemAny := range items {
 		item := itemAny.(map[string]any)
 		if subItemsAny, ok := item["item"]; ok { // if item is a folder
-			_filterResponsesByStatus(subItemsAny.([]any), statusRanges)
+			folderPath := fmt.Sprint(item["name"])
+			if len(folderPaths) > 0 {
+				folderPath = folderPaths[len(folderPaths)-1] + "/" + folderPath
+			}
+			subFolderPaths := append(folderPaths[:len(folderPaths):len(folderPaths)], folderPath)
+			_filterResponsesByStatus(subItemsAny.([]any), subFolderPaths, statuses, folderStatuses)
 		} else { // if item is an endpoint
+			filter := statuses
+			for _, folderFilter := range folderStatuses {
+				if matchesAnyGlob([]string{folderFilter.folder}, folderPaths) {
+					filter = folderFilter.statuses
+					break
+				}
+			}
 			responses := item["response"].([]any)
 			for j := len(responses) - 1; j >= 0; j-- {
 				response := responses[j].(map[string]any)
-				inRange := false
-				for _, statusRange := range statusRanges {
-					code := int(response["code"].(float64))
-					if code >= statusRange[0] && code <= statusRange[1] {
-						inRange = true
-						break
-					}
-				}
-				if !inRange {
+				code := int(response["code"].(float64))
+				if !filter.keeps(code) {
 					responses = slices.Delete(responses, j, j+1)
 					item["response"] = responses
 				}
@@ -151,21 +333,25 @@ func _addLevelProperty(items []any, level int) {
 	}
 }
 
-// executeTmpl uses a template and FuncMap to convert the collection to plaintext
-// and saves to the given open destination file without closing it. `Seek(0, 0)` is then
-// called on the file so the file pointer is at the beginning of the file unless an
-// error occurs.
-func executeTmpl(collection map[string]any, openAnsFile *os.File, tmplName, tmplStr string) error {
-	tmpl, err := template.New(tmplName).Funcs(funcMap).Parse(tmplStr)
+// executeTmplSet parses a template set with the given template options, such as
+// "missingkey=error", and executes it with the given collection, writing the result to
+// the given writer.
+func executeTmplSet(collection map[string]any, w io.Writer, set tmplSet, funcMap template.FuncMap, tmplOptions []string) error {
+	tmpl, err := set.parse(funcMap, tmplOptions)
 	if err != nil {
-		return fmt.Errorf("template parsing error: %s", err)
+		return err
 	}
 
-	err = tmpl.Execute(openAnsFile, collection)
+	return tmpl.Execute(w, collection)
+}
+
+// executeHTMLTmplSet is like executeTmplSet, but values are escaped for HTML depending
+// on where in the HTML they are output.
+func executeHTMLTmplSet(collection map[string]any, w io.Writer, set tmplSet, funcMap template.FuncMap, tmplOptions []string) error {
+	tmpl, err := set.parseHTML(funcMap, tmplOptions)
 	if err != nil {
 		return err
 	}
 
-	openAnsFile.Seek(0, 0)
-	return nil
+	return tmpl.Execute(w, collection)
 }
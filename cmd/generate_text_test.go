@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"encoding/json"
+	"math"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -32,50 +35,89 @@ func assertGenerateNoDiff(t *testing.T, jsonPath, tmplPath, wantPath string) {
 		return
 	}
 
-	err := AssertGenerateNoDiff(jsonPath, tmplPath, wantPath, nil)
+	err := AssertGenerateNoDiff(Target{Input: jsonPath, Template: TemplateList{tmplPath}}, wantPath)
 	if err != nil {
 		t.Error(err)
 	}
 }
 
-func TestParseStatusRanges(t *testing.T) {
+func TestParseStatusFilter(t *testing.T) {
 	tests := []struct {
 		input string
-		want  [][]int
+		want  *statusFilter
 	}{
 		{"", nil},
-		{"200", [][]int{{200, 200}}},
-		{"200-299", [][]int{{200, 299}}},
-		{"200-299,400-499", [][]int{{200, 299}, {400, 499}}},
-		{"200-200", [][]int{{200, 200}}},
+		{"200", &statusFilter{include: [][]int{{200, 200}}}},
+		{"200-299", &statusFilter{include: [][]int{{200, 299}}}},
+		{"200-299,400-499", &statusFilter{include: [][]int{{200, 299}, {400, 499}}}},
+		{"200-200", &statusFilter{include: [][]int{{200, 200}}}},
+		{"200-", &statusFilter{include: [][]int{{200, math.MaxInt}}}},
+		{"2xx,4XX", &statusFilter{include: [][]int{{200, 299}, {400, 499}}}},
+		{"2xx,!204", &statusFilter{include: [][]int{{200, 299}}, exclude: [][]int{{204, 204}}}},
+		{"!404", &statusFilter{exclude: [][]int{{404, 404}}}},
+		{" 2xx, !5xx ", &statusFilter{include: [][]int{{200, 299}}, exclude: [][]int{{500, 599}}}},
 	}
 
 	for _, test := range tests {
 		t.Run(test.input, func(t *testing.T) {
-			ans, err := parseStatusRanges(test.input)
+			ans, err := parseStatusFilter(test.input)
 			if err != nil {
 				t.Error(err)
 				return
 			}
 			if !reflect.DeepEqual(ans, test.want) {
-				t.Errorf("parseStatusRanges(%q) = %v, want %v", test.input, ans, test.want)
+				t.Errorf("parseStatusFilter(%q) = %v, want %v", test.input, ans, test.want)
 				return
 			}
 		})
 	}
 }
 
-func TestParseStatusRangesWithInvalidInput(t *testing.T) {
-	inputs := []string{"200-299-300", "a-299", "200-b", "200-", "-299", "-", "a"}
+func TestParseStatusFilterWithInvalidInput(t *testing.T) {
+	inputs := []string{"200-299-300", "a-299", "200-b", "-299", "-", "a", "0xx", "2x", "299-200", "200,,300", "!"}
 	for _, input := range inputs {
 		t.Run(input, func(t *testing.T) {
-			if statusRanges, err := parseStatusRanges(input); err == nil {
-				t.Errorf("parseStatusRanges(%q) = (%v, nil), want non-nil error", input, statusRanges)
+			if statuses, err := parseStatusFilter(input); err == nil {
+				t.Errorf("parseStatusFilter(%q) = (%v, nil), want non-nil error", input, statuses)
 			}
 		})
 	}
 }
 
+func TestParseStatusFilterErrorPosition(t *testing.T) {
+	_, err := parseStatusFilter("2xx, 3yy")
+	want := `invalid status "3yy" at position 6 of "2xx, 3yy"`
+	if err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("parseStatusFilter(\"2xx, 3yy\") returned error %q, want error starting with %q", err, want)
+	}
+}
+
+func TestStatusFilterKeeps(t *testing.T) {
+	statuses, err := parseStatusFilter("2xx,500-,!204,!503")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{200, true},
+		{204, false},
+		{299, true},
+		{404, false},
+		{500, true},
+		{503, false},
+		{999, true},
+	}
+
+	for _, test := range tests {
+		if ans := statuses.keeps(test.code); ans != test.want {
+			t.Errorf("keeps(%d) = %v, want %v", test.code, ans, test.want)
+		}
+	}
+}
+
 func TestParseEmptyCollection(t *testing.T) {
 	collection, err := parseCollection([]byte(""))
 	if err == nil {
@@ -89,6 +131,14 @@ func TestGenerateText(t *testing.T) {
 	assertGenerateNoDiff(t, inputPath, "", wantOutputPath)
 }
 
+func TestGenerateTextTwice(t *testing.T) {
+	// Header links must not depend on what was generated before.
+	inputPath := "../samples/calendar-API.postman_collection.json"
+	wantOutputPath := "../samples/calendar-API-v1.md"
+	assertGenerateNoDiff(t, inputPath, "", wantOutputPath)
+	assertGenerateNoDiff(t, inputPath, "", wantOutputPath)
+}
+
 func TestGenerateTextWithCustomTemplate(t *testing.T) {
 	inputPath := "../samples/minimal-calendar-API.postman_collection.json"
 	customTmplPath := "../samples/custom.tmpl"
@@ -142,6 +192,44 @@ func TestParseCollectionWithOldSchema(t *testing.T) {
 	}
 }
 
+func TestParseCollectionWithTrailingData(t *testing.T) {
+	jsonStr := `{"info": {"schema": "` + collectionSchema + `"}, "item": []} {}`
+	if collection, err := parseCollection([]byte(jsonStr)); err == nil {
+		t.Errorf("want (nil, error), got a nil error and a non-nil collection: %v", collection)
+	}
+}
+
+func TestReadCollectionFromStdin(t *testing.T) {
+	// The body is longer than bufio.Scanner's default limit of 64 KiB per line, and its
+	// line endings must not change.
+	body := strings.Repeat("a", 100_000) + "\r\nb"
+	bodyJSON, _ := json.Marshal(body)
+	jsonStr := `{"info": {"name": "big", "schema": "` + collectionSchema + `"}, "item": [` +
+		`{"name": "e", "request": {}, "response": [{"code": 200, "body": ` + string(bodyJSON) + `}]}]}`
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	go func() {
+		w.Write([]byte(jsonStr))
+		w.Close()
+	}()
+
+	collection, err := readCollection("-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := collection["item"].([]any)[0].(map[string]any)
+	ans := endpoint["response"].([]any)[0].(map[string]any)["body"].(string)
+	if ans != body {
+		t.Errorf("got a body of length %d, want the original body of length %d", len(ans), len(body))
+	}
+}
+
 // getCollection loads JSON from the file at the given path and converts the JSON to a
 // map.
 func getCollection(t *testing.T, jsonPath string) (map[string]any, error) {
@@ -210,7 +298,7 @@ func TestFilterResponses(t *testing.T) {
 		return
 	}
 
-	filterResponsesByStatus(collection, [][]int{{200, 200}})
+	filterResponsesByStatus(collection, &statusFilter{include: [][]int{{200, 200}}}, nil)
 	items := collection["item"].([]any)
 	assertAllStatuses200(t, items)
 }
@@ -223,11 +311,50 @@ func TestFilterResponsesWithFolders(t *testing.T) {
 		return
 	}
 
-	filterResponsesByStatus(collection, [][]int{{200, 200}})
+	filterResponsesByStatus(collection, &statusFilter{include: [][]int{{200, 200}}}, nil)
 	items := collection["item"].([]any)
 	assertAllStatuses200(t, items)
 }
 
+func TestFilterResponsesWithFolderStatuses(t *testing.T) {
+	jsonPath := "../samples/calendar-API.postman_collection.json"
+	collection, err := getCollection(t, jsonPath)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	folderStatuses := []folderStatusFilter{
+		{folder: "POST endpoints", statuses: &statusFilter{include: [][]int{{201, 201}}}},
+	}
+	filterResponsesByStatus(collection, &statusFilter{exclude: [][]int{{0, math.MaxInt}}}, folderStatuses)
+
+	responseCounts := make(map[string]int)
+	var countResponses func(items []any)
+	countResponses = func(items []any) {
+		for _, itemAny := range items {
+			item := itemAny.(map[string]any)
+			if subItemsAny, ok := item["item"]; ok {
+				countResponses(subItemsAny.([]any))
+			} else {
+				responseCounts[item["name"].(string)] = len(item["response"].([]any))
+			}
+		}
+	}
+	countResponses(collection["item"].([]any))
+
+	want := map[string]int{
+		"create account":   1,
+		"log in":           0,
+		"get all accounts": 0,
+		"edit account":     0,
+		"delete account":   0,
+	}
+	if !reflect.DeepEqual(responseCounts, want) {
+		t.Errorf("response counts = %v, want %v", responseCounts, want)
+	}
+}
+
 func TestAddLevelProperty(t *testing.T) {
 	jsonPath := "../samples/calendar-API.postman_collection.json"
 	collection, err := getCollection(t, jsonPath)
@@ -243,18 +370,16 @@ func TestAddLevelProperty(t *testing.T) {
 
 func TestGetDestFileStdout(t *testing.T) {
 	destFile, destName, err := openDestFile("-", "", false)
-	if destFile != os.Stdout || destName != "-" || err != nil {
-		t.Errorf("openDestFile(\"-\", \"\") = (%p, %q, %q), want (%p, \"-\", nil)", destFile, destName, err, os.Stdout)
+	if err != nil || destName != "-" || destFile.temp != nil {
+		t.Errorf("openDestFile(\"-\", \"\") = (%v, %q, %v), want (<stdout>, \"-\", nil)", destFile, destName, err)
 	}
 }
 
 func TestGetDestFileExistingFileErr(t *testing.T) {
 	destFile, destName, err := openDestFile("../LICENSE", "", false)
 	if err == nil {
-		t.Errorf("openDestFile(\"../LICENSE\", \"\", false) = (%p, %q, nil), want non-nil error", destFile, destName)
-		if destName != "-" {
-			destFile.Close()
-		}
+		t.Errorf("openDestFile(\"../LICENSE\", \"\", false) = (%v, %q, nil), want non-nil error", destFile, destName)
+		destFile.abort()
 	}
 }
 
@@ -271,40 +396,29 @@ func TestGetDestFile(t *testing.T) {
 			destFile, destName, err := openDestFile(test.originalDestName, test.collectionName, false)
 			if err != nil {
 				t.Errorf(
-					"openDestFile(%q, %q) = (%p, %q, %v), want nil error",
+					"openDestFile(%q, %q) = (%v, %q, %v), want nil error",
 					test.originalDestName, test.collectionName, destFile, destName, err,
 				)
 				return
 			}
-			if destFile == os.Stdout {
-				t.Errorf(
-					"openDestFile(%q, %q) = (os.Stdout, %q, nil), want non-std file",
-					test.originalDestName, test.collectionName, destName,
-				)
-				return
-			}
-			if destFile == os.Stdin {
-				t.Errorf(
-					"openDestFile(%q, %q) = (os.Stdin, %q, nil), want non-std file",
-					test.originalDestName, test.collectionName, destName,
-				)
-				return
-			}
-			if destFile == os.Stderr {
+			if destFile.temp == nil {
 				t.Errorf(
-					"openDestFile(%q, %q) = (os.Stderr, %q, nil), want non-std file",
+					"openDestFile(%q, %q) = (<stdout>, %q, nil), want a file",
 					test.originalDestName, test.collectionName, destName,
 				)
 				return
 			}
-			destFile.Close()
-			defer os.Remove(destName)
+			destFile.abort()
 			if destName != test.wantName {
 				t.Errorf(
-					"openDestFile(%q, %q) = (%p, %q, nil), want (%p, %q, nil)",
+					"openDestFile(%q, %q) = (%v, %q, nil), want (%v, %q, nil)",
 					test.originalDestName, test.collectionName, destFile, destName, destFile, test.wantName,
 				)
 			}
+			if FileExists(destName) {
+				t.Errorf("openDestFile(%q, %q) created %q before committing", test.originalDestName, test.collectionName, destName)
+				os.Remove(destName)
+			}
 		})
 	}
 }
@@ -313,34 +427,87 @@ func TestGetDestFileWithEmptyNames(t *testing.T) {
 	wantDestName := "collection.md"
 	destFile, destName, err := openDestFile("", "", false)
 	if err != nil || destName != wantDestName || destFile == nil {
-		t.Errorf("openDestFile(\"\", \"\") = (%p, %q, %v), want (non-nil *os.File, %q, nil)", destFile, destName, err, wantDestName)
-	}
-	if destFile == os.Stdout {
-		t.Error("openDestFile(\"\", \"\") returned os.Stdout, want non-std file pointer")
-	} else if destFile == os.Stdin {
-		t.Error("openDestFile(\"\", \"\") returned os.Stdin, want non-std file pointer")
-	} else if destFile == os.Stderr {
-		t.Error("openDestFile(\"\", \"\") returned os.Stderr, want non-std file pointer")
-	} else if err == nil {
-		destFile.Close()
-		os.Remove(destName)
+		t.Errorf("openDestFile(\"\", \"\") = (%v, %q, %v), want (<file>, %q, nil)", destFile, destName, err, wantDestName)
+		return
+	}
+	if destFile.temp == nil {
+		t.Error("openDestFile(\"\", \"\") returned stdout, want a file")
 	}
+	destFile.abort()
 }
 
 func TestGetDestFileNameReplaceError(t *testing.T) {
 	destFile, destName, err := openDestFile("samples/calendar-API-v1.md", "", false)
 	if err == nil {
 		t.Errorf("openDestFile targeting an existing file returned nil error, want non-nil error")
-		t.Errorf("openDestFile(<existing file>, \"\") = (%p, %q, nil), want (nil, \"\", <non-nil error>)", destFile, destName)
-		if destName != "-" {
-			destFile.Close()
-		}
+		t.Errorf("openDestFile(<existing file>, \"\") = (%v, %q, nil), want (nil, \"\", <non-nil error>)", destFile, destName)
+		destFile.abort()
 	}
 }
 
-func TestExecuteTmplWithInvalidTemplate(t *testing.T) {
-	err := executeTmpl(nil, nil, "api v1", "# {{ .Name ")
-	if err == nil {
-		t.Errorf("executeTmpl(nil, nil, \"api v1\", \"# {{ .Name \") = nil, want non-nil error")
+func TestExecuteTmplSetWithInvalidTemplate(t *testing.T) {
+	set := tmplSet{sources: []tmplSource{{"api v1", "# {{ .Name "}}}
+	if err := executeTmplSet(nil, nil, set, nil, nil); err == nil {
+		t.Errorf("executeTmplSet with template \"# {{ .Name \" = nil, want non-nil error")
+	}
+}
+
+func TestRenderTextEscaping(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "page.tmpl")
+	tmplStr := `<h1 title="{{.info.name}}">{{.info.name}}</h1>` + "\n" + `{{allowJsonOrPlaintext .info.description}}`
+	if err := os.WriteFile(tmplPath, []byte(tmplStr), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		html bool
+		want string
+	}{
+		{"markdown", false, `<h1 title="a "quoted" <b>name</b>">a "quoted" <b>name</b></h1>` + "\n" + `{"a": "<b>"}`},
+		{"html", true, `<h1 title="a &#34;quoted&#34; &lt;b&gt;name&lt;/b&gt;">a &#34;quoted&#34; &lt;b&gt;name&lt;/b&gt;</h1>` + "\n" + `{&#34;a&#34;: &#34;&lt;b&gt;&#34;}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collection := map[string]any{
+				"info": map[string]any{"name": `a "quoted" <b>name</b>`, "description": `{"a": "<b>"}`},
+				"item": []any{},
+			}
+			var b strings.Builder
+			if err := renderText(collection, &b, renderOptions{tmplPaths: []string{tmplPath}, html: test.html}); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != test.want {
+				t.Errorf("output = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderDefaultTemplateAsHTML(t *testing.T) {
+	collection, err := readCollection("../samples/calendar-API.postman_collection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := collection["item"].([]any)
+	items[0].(map[string]any)["name"] = "POST <endpoints>"
+	var b strings.Builder
+	if err := renderText(collection, &b, renderOptions{html: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"<h1>POST &lt;endpoints&gt;</h1>",
+		"<h2>create account</h2>",
+		"<h3>sample request body</h3>",
+		"<h3>sample response to valid input (status: 201 Created)</h3>",
+		"* [POST &lt;endpoints&gt;](#post-endpoints)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "&lt;h") {
+		t.Errorf("output contains escaped header tags:\n%s", got)
 	}
 }
@@ -0,0 +1,94 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const maxHeaderLevel = 6
+
+var headingOverflows = []string{"clamp", "bold"}
+
+// headingStrategy converts levels to header levels. Level 1 is the level of the
+// collection's name and of the outermost items.
+type headingStrategy struct {
+	// base is the header level of level 1. If zero, it is 1.
+	base int
+
+	// overflow is what to do with headers that would be deeper than h6. "clamp" (or
+	// empty) uses h6, and "bold" uses bold text instead of a header.
+	overflow string
+}
+
+// parseHeadingStrategy validates a header base and overflow strategy and combines them.
+func parseHeadingStrategy(base int, overflow string) (headingStrategy, error) {
+	if base < 0 || base > maxHeaderLevel {
+		return headingStrategy{}, fmt.Errorf("the heading base must be from 1 to %d, got %d", maxHeaderLevel, base)
+	}
+	if len(overflow) > 0 && !slices.Contains(headingOverflows, overflow) {
+		return headingStrategy{}, fmt.Errorf(
+			"unknown heading overflow %q. The heading overflows are: %s",
+			overflow, strings.Join(headingOverflows, ", "),
+		)
+	}
+	return headingStrategy{base: base, overflow: overflow}, nil
+}
+
+// headerLevel returns the header level for the given level, or 0 if the header is too
+// deep and should be bold text instead.
+func (h headingStrategy) headerLevel(level int) int {
+	base := h.base
+	if base == 0 {
+		base = 1
+	}
+	headerLevel := max(level+base-1, 1)
+	if headerLevel > maxHeaderLevel {
+		if h.overflow == "bold" {
+			return 0
+		}
+		return maxHeaderLevel
+	}
+	return headerLevel
+}
+
+// headingTag returns the name of the HTML tag for a header at the given level, such as
+// "h2", or "strong" if the header is too deep.
+func (h headingStrategy) headingTag(level int) string {
+	headerLevel := h.headerLevel(level)
+	if headerLevel == 0 {
+		return "strong"
+	}
+	return fmt.Sprintf("h%d", headerLevel)
+}
+
+// markdownHeading formats text as a markdown header at the given level, such as
+// "## text", or as bold text if the header is too deep.
+func (h headingStrategy) markdownHeading(level int, text string) string {
+	headerLevel := h.headerLevel(level)
+	if headerLevel == 0 {
+		return "**" + text + "**"
+	}
+	return strings.Repeat("#", headerLevel) + " " + text
+}
+
+// htmlHeading formats text as an HTML header at the given level, such as
+// "<h2>text</h2>", or as bold text if the header is too deep.
+func (h headingStrategy) htmlHeading(level int, text string) string {
+	tag := h.headingTag(level)
+	return fmt.Sprintf("<%s>%s</%s>", tag, text, tag)
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHeadingTag(t *testing.T) {
+	tests := []struct {
+		base     int
+		overflow string
+		level    int
+		want     string
+	}{
+		{0, "", 1, "h1"},
+		{0, "", 3, "h3"},
+		{0, "", 6, "h6"},
+		{0, "", 8, "h6"},
+		{0, "clamp", 7, "h6"},
+		{0, "bold", 6, "h6"},
+		{0, "bold", 7, "strong"},
+		{2, "", 1, "h2"},
+		{2, "", 5, "h6"},
+		{2, "", 6, "h6"},
+		{2, "bold", 6, "strong"},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("base %d %q level %d", test.base, test.overflow, test.level)
+		t.Run(name, func(t *testing.T) {
+			headings, err := parseHeadingStrategy(test.base, test.overflow)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ans := headings.headingTag(test.level); ans != test.want {
+				t.Errorf("headingTag(%d) = %q, want %q", test.level, ans, test.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownHeading(t *testing.T) {
+	tests := []struct {
+		base     int
+		overflow string
+		level    int
+		want     string
+	}{
+		{0, "", 1, "# title"},
+		{3, "", 2, "#### title"},
+		{0, "", 9, "###### title"},
+		{0, "bold", 9, "**title**"},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("base %d %q level %d", test.base, test.overflow, test.level)
+		t.Run(name, func(t *testing.T) {
+			headings, err := parseHeadingStrategy(test.base, test.overflow)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ans := headings.markdownHeading(test.level, "title"); ans != test.want {
+				t.Errorf("markdownHeading(%d, \"title\") = %q, want %q", test.level, ans, test.want)
+			}
+		})
+	}
+}
+
+func TestParseHeadingStrategyWithInvalidInput(t *testing.T) {
+	tests := []struct {
+		base     int
+		overflow string
+	}{
+		{-1, ""},
+		{7, ""},
+		{1, "wrap"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d %q", test.base, test.overflow), func(t *testing.T) {
+			if _, err := parseHeadingStrategy(test.base, test.overflow); err == nil {
+				t.Errorf("parseHeadingStrategy(%d, %q) returned nil error, want non-nil error", test.base, test.overflow)
+			}
+		})
+	}
+}
+
+func TestRenderTextWithDeepFolders(t *testing.T) {
+	var item any = map[string]any{"name": "endpoint", "request": map[string]any{}, "response": []any{}}
+	for i := 0; i < 7; i++ {
+		item = map[string]any{"name": fmt.Sprintf("folder %d", i), "item": []any{item}}
+	}
+	collection := map[string]any{
+		"info": map[string]any{"name": "deep"},
+		"item": []any{item},
+	}
+	var b strings.Builder
+	opts := renderOptions{headings: headingStrategy{base: 2, overflow: "bold"}}
+	if err := renderText(collection, &b, opts); err != nil {
+		t.Fatal(err)
+	}
+	ans := b.String()
+	if !strings.HasPrefix(ans, "## deep") {
+		t.Errorf("output starts with %q, want \"## deep\"", strings.SplitN(ans, "\n", 2)[0])
+	}
+	if strings.Contains(ans, "<h1>") || strings.Contains(ans, "<h7>") {
+		t.Error("output contains <h1> or <h7>, want only <h2> through <h6> and <strong>")
+	}
+	if !strings.Contains(ans, "<strong>endpoint</strong>") {
+		t.Error("output doesn't contain <strong>endpoint</strong>")
+	}
+}
+
+func TestHTMLHeading(t *testing.T) {
+	headings := headingStrategy{base: 2, overflow: "bold"}
+	if ans := headings.htmlHeading(1, "title"); ans != "<h2>title</h2>" {
+		t.Errorf("htmlHeading(1, \"title\") = %q, want %q", ans, "<h2>title</h2>")
+	}
+	if ans := headings.htmlHeading(6, "title"); ans != "<strong>title</strong>" {
+		t.Errorf("htmlHeading(6, \"title\") = %q, want %q", ans, "<strong>title</strong>")
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const itemSeparator = "----------------------------------------"
+
+var markdownHeadingPattern = regexp.MustCompile(`^(#{1,6}) (.*)$`)
+var htmlHeadingPattern = regexp.MustCompile(`^\s*<(h[1-6]|strong)>(.*)</(?:h[1-6]|strong)>(?: - (.*))?$`)
+var boldHeadingPattern = regexp.MustCompile(`^\*\*(.*)\*\*$`)
+var methodLinePattern = regexp.MustCompile("^([A-Z]+) `(/[^`]*)`$")
+var sampleResponsePattern = regexp.MustCompile(`^sample response(?: to (.*?))? \(status: (\d+) ?(.*)\)$`)
+var tocLinePattern = regexp.MustCompile(`^\s*\* (?:[\d.]+ )?\[.*\]\(.*\)`)
+var htmlLayoutPattern = regexp.MustCompile(`^\s*</?(?:details|summary)(?: open)?>\s*$`)
+
+// importHeading is a header found while importing markdown.
+type importHeading struct {
+	// level is the header level, or 7 for bold text used instead of a header.
+	level       int
+	text        string
+	description string
+}
+
+// markdownImporter converts markdown created by the default or minimal template back to
+// a collection.
+type markdownImporter struct {
+	lines []string
+	i     int
+}
+
+// importMarkdown converts markdown created by the default or minimal template back to a
+// collection. Names, descriptions, methods, URL paths, sample request bodies, and sample
+// responses are recovered. Other data, such as URL hosts and headers, isn't in the
+// markdown, so it can't be recovered. Line endings become "\n". Code blocks must not
+// contain lines of only "```".
+func importMarkdown(markdown string) (map[string]any, error) {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	m := markdownImporter{lines: strings.Split(markdown, "\n")}
+
+	m.skipBlankLines()
+	title, ok := m.heading()
+	if !ok {
+		return nil, errors.New("no title found. The markdown must start with the collection's name as a header")
+	}
+	m.i++
+	info := map[string]any{"name": title.text, "schema": collectionSchema}
+	var descriptionLines []string
+	for ; m.i < len(m.lines) && m.lines[m.i] != itemSeparator; m.i++ {
+		if !tocLinePattern.MatchString(m.lines[m.i]) {
+			descriptionLines = append(descriptionLines, m.lines[m.i])
+		}
+	}
+	if description := strings.TrimSpace(strings.Join(descriptionLines, "\n")); len(description) > 0 {
+		info["description"] = description
+	}
+
+	// Each open folder is kept with its header level so that the parent of each item can
+	// be found. The root is a stand-in folder for the collection's items.
+	type openFolder struct {
+		level  int
+		folder map[string]any
+	}
+	root := map[string]any{"item": []any{}}
+	folders := []openFolder{{0, root}}
+	for m.i < len(m.lines) {
+		if m.lines[m.i] != itemSeparator {
+			m.i++
+			continue
+		}
+		m.i++
+		heading, ok := m.nextHeading()
+		if !ok {
+			break
+		}
+		item := m.parseItem(heading)
+		for len(folders) > 1 && folders[len(folders)-1].level >= heading.level {
+			folders = folders[:len(folders)-1]
+		}
+		parent := folders[len(folders)-1].folder
+		parent["item"] = append(parent["item"].([]any), item)
+		if _, ok := item["item"]; ok {
+			folders = append(folders, openFolder{heading.level, item})
+		}
+	}
+
+	return map[string]any{"info": info, "item": root["item"]}, nil
+}
+
+// parseItem parses an item after its header. An item with a method and path is an
+// endpoint, and any other item is a folder.
+func (m *markdownImporter) parseItem(heading importHeading) map[string]any {
+	item := map[string]any{"name": heading.text}
+	if len(heading.description) > 0 {
+		item["description"] = heading.description
+	}
+	m.skipLayoutLines()
+	if m.i >= len(m.lines) {
+		item["item"] = []any{}
+		return item
+	}
+	match := methodLinePattern.FindStringSubmatch(m.lines[m.i])
+	if match == nil {
+		item["item"] = []any{}
+		return item
+	}
+	m.i++
+
+	request := map[string]any{
+		"method": match[1],
+		"header": []any{},
+		"url":    importURL(match[2]),
+	}
+	responses := make([]any, 0)
+	var descriptionLines []string
+	for m.i < len(m.lines) && m.lines[m.i] != itemSeparator {
+		heading, ok := m.heading()
+		if !ok {
+			if !htmlLayoutPattern.MatchString(m.lines[m.i]) {
+				descriptionLines = append(descriptionLines, m.lines[m.i])
+			}
+			m.i++
+			continue
+		}
+		m.i++
+		if heading.text == "sample request body" {
+			language, body := m.codeBlock()
+			request["body"] = importRequestBody(language, body)
+		} else if match := sampleResponsePattern.FindStringSubmatch(heading.text); match != nil {
+			language, body := m.codeBlock()
+			if body == "(no response body)" {
+				body = ""
+			}
+			code, _ := strconv.Atoi(match[2])
+			responses = append(responses, map[string]any{
+				"name":                     match[1],
+				"status":                   match[3],
+				"code":                     float64(code),
+				"_postman_previewlanguage": language,
+				"header":                   []any{},
+				"body":                     body,
+			})
+		} else {
+			descriptionLines = append(descriptionLines, m.lines[m.i-1])
+		}
+	}
+	if description := strings.TrimSpace(strings.Join(descriptionLines, "\n")); len(description) > 0 {
+		request["description"] = description
+	}
+
+	item["request"] = request
+	item["response"] = responses
+	return item
+}
+
+// heading parses the current line as a header, if it is one.
+func (m *markdownImporter) heading() (importHeading, bool) {
+	if m.i >= len(m.lines) {
+		return importHeading{}, false
+	}
+	line := m.lines[m.i]
+	if match := markdownHeadingPattern.FindStringSubmatch(line); match != nil {
+		return importHeading{level: len(match[1]), text: match[2]}, true
+	}
+	if match := htmlHeadingPattern.FindStringSubmatch(line); match != nil {
+		level := 7
+		if match[1] != "strong" {
+			level = int(match[1][1] - '0')
+		}
+		return importHeading{level: level, text: match[2], description: match[3]}, true
+	}
+	if match := boldHeadingPattern.FindStringSubmatch(line); match != nil {
+		return importHeading{level: 7, text: match[1]}, true
+	}
+	return importHeading{}, false
+}
+
+// nextHeading skips blank and layout lines and then parses and skips a header.
+func (m *markdownImporter) nextHeading() (importHeading, bool) {
+	m.skipLayoutLines()
+	heading, ok := m.heading()
+	if ok {
+		m.i++
+	}
+	return heading, ok
+}
+
+// codeBlock skips to the next code block and returns its language and content.
+func (m *markdownImporter) codeBlock() (string, string) {
+	for m.i < len(m.lines) && !strings.HasPrefix(m.lines[m.i], "```") {
+		m.i++
+	}
+	if m.i >= len(m.lines) {
+		return "", ""
+	}
+	language := strings.TrimPrefix(m.lines[m.i], "```")
+	m.i++
+	start := m.i
+	for m.i < len(m.lines) && m.lines[m.i] != "```" {
+		m.i++
+	}
+	content := strings.Join(m.lines[start:min(m.i, len(m.lines))], "\n")
+	m.i++
+	return language, content
+}
+
+func (m *markdownImporter) skipBlankLines() {
+	for m.i < len(m.lines) && len(strings.TrimSpace(m.lines[m.i])) == 0 {
+		m.i++
+	}
+}
+
+// skipLayoutLines skips blank lines and the HTML tags the default template uses for
+// layout, such as "<details open>".
+func (m *markdownImporter) skipLayoutLines() {
+	for m.i < len(m.lines) && (len(strings.TrimSpace(m.lines[m.i])) == 0 || htmlLayoutPattern.MatchString(m.lines[m.i])) {
+		m.i++
+	}
+}
+
+// importURL converts a URL path such as "/v1/users" to a collection URL.
+func importURL(urlPath string) map[string]any {
+	segments := make([]any, 0)
+	for _, segment := range strings.Split(strings.Trim(urlPath, "/"), "/") {
+		if len(segment) > 0 {
+			segments = append(segments, segment)
+		}
+	}
+	return map[string]any{"raw": urlPath, "path": segments}
+}
+
+// importRequestBody converts a sample request body's language and content to a
+// collection request body.
+func importRequestBody(language, body string) map[string]any {
+	result := map[string]any{"mode": "raw", "raw": body}
+	if len(language) > 0 {
+		result["options"] = map[string]any{"raw": map[string]any{"language": language}}
+	}
+	return result
+}
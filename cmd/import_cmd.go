@@ -0,0 +1,82 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [docs.md [collection.json]]",
+	Short: "Convert markdown back to a collection",
+	Long: "Convert markdown back to a collection\n\n" +
+		"The markdown must have been created with the default or minimal template, although it\n" +
+		"may have been edited since. Names, descriptions, methods, URL paths, sample request\n" +
+		"bodies, and sample responses are recovered as a Postman v2.1 collection. Data that\n" +
+		"isn't in the markdown, such as URL hosts and headers, can't be recovered.",
+	Example: `  pm2md import docs.md
+  pm2md import docs.md collection.json
+  pm2md import docs.md -`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: importRunFunc,
+}
+
+// importRunFunc converts a markdown file or stdin to a collection and saves it to a file
+// or prints it to stdout.
+func importRunFunc(cmd *cobra.Command, args []string) error {
+	markdownBytes, err := ReadInput(args[0])
+	if err != nil {
+		return err
+	}
+	collection, err := importMarkdown(string(markdownBytes))
+	if err != nil {
+		return fmt.Errorf("%s: %s", args[0], err)
+	}
+	jsonBytes, err := json.MarshalIndent(collection, "", "\t")
+	if err != nil {
+		return err
+	}
+	jsonBytes = append(jsonBytes, '\n')
+
+	var destPath string
+	if len(args) == 2 {
+		destPath = args[1]
+	}
+	if destPath == "-" {
+		_, err := os.Stdout.Write(jsonBytes)
+		return err
+	}
+	if len(destPath) == 0 {
+		fileName := FormatFileName(collection["info"].(map[string]any)["name"].(string))
+		if len(fileName) == 0 {
+			fileName = "collection"
+		}
+		destPath = CreateUniqueFileName(fileName, ".postman_collection.json")
+	} else if !strings.HasSuffix(strings.ToLower(destPath), ".json") {
+		return fmt.Errorf("%q must be \"-\" or end with \".json\"", destPath)
+	} else if FileExists(destPath) && !ConfirmReplaceExistingFile {
+		return fmt.Errorf("file %q already exists. Run the command again with the --replace flag to confirm replacing it", destPath)
+	}
+	if err := writeFileAtomic(destPath, jsonBytes); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Created %q\n", destPath)
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// importedData returns the data of a collection that importMarkdown can recover, one
+// string per folder, endpoint, and sample response. Line endings are normalized, and the
+// language of an empty request body is left out because the templates don't show it. If
+// withDescriptions is false, descriptions are left out.
+func importedData(items []any, prefix string, withDescriptions bool) []string {
+	data := make([]string, 0)
+	for _, itemAny := range items {
+		item := itemAny.(map[string]any)
+		name := prefix + fmt.Sprint(item["name"])
+		description := ""
+		if withDescriptions {
+			description = strings.TrimSpace(descriptionText(item["description"]))
+		}
+		if subItems, ok := item["item"].([]any); ok {
+			data = append(data, fmt.Sprintf("folder %q %q", name, description))
+			data = append(data, importedData(subItems, name+"/", withDescriptions)...)
+			continue
+		}
+		request := item["request"].(map[string]any)
+		method, urlPath := methodAndPath(item)
+		if withDescriptions {
+			description += "|" + strings.TrimSpace(descriptionText(request["description"]))
+		}
+		var body, language string
+		if bodyMap, ok := request["body"].(map[string]any); ok {
+			body, _ = bodyMap["raw"].(string)
+			if options, ok := bodyMap["options"].(map[string]any); ok {
+				language, _ = options["raw"].(map[string]any)["language"].(string)
+			}
+		}
+		body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+		if len(body) == 0 {
+			language = ""
+		}
+		data = append(data, fmt.Sprintf(
+			"endpoint %q %q %s %s %q %q", name, description, method, urlPath, language, body,
+		))
+		for _, responseAny := range item["response"].([]any) {
+			response := responseAny.(map[string]any)
+			responseBody, _ := response["body"].(string)
+			data = append(data, fmt.Sprintf(
+				"response %q %v %q %q %q",
+				response["name"], response["code"], response["status"],
+				response["_postman_previewlanguage"], strings.TrimSpace(strings.ReplaceAll(responseBody, "\r\n", "\n")),
+			))
+		}
+	}
+	return data
+}
+
+func TestImportMarkdownRoundTrip(t *testing.T) {
+	tests := []struct {
+		jsonPath, tmplPath string
+		withDescriptions   bool
+	}{
+		{"../samples/calendar-API.postman_collection.json", "", true},
+		{"../samples/minimal-calendar-API.postman_collection.json", "minimal.tmpl", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.jsonPath+" "+test.tmplPath, func(t *testing.T) {
+			collection, err := readCollection(test.jsonPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := importedData(collection["item"].([]any), "", test.withDescriptions)
+
+			var b strings.Builder
+			if err := renderText(collection, &b, renderOptions{tmplPaths: []string{test.tmplPath}}); err != nil {
+				t.Fatal(err)
+			}
+			imported, err := importMarkdown(b.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if imported["info"].(map[string]any)["name"] != collection["info"].(map[string]any)["name"] {
+				t.Errorf("got collection name %q, want %q", imported["info"].(map[string]any)["name"], collection["info"].(map[string]any)["name"])
+			}
+			ans := importedData(imported["item"].([]any), "", test.withDescriptions)
+			if !reflect.DeepEqual(ans, want) {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(ans, "\n"), strings.Join(want, "\n"))
+			}
+
+			var again strings.Builder
+			if err := renderText(imported, &again, renderOptions{tmplPaths: []string{test.tmplPath}}); err != nil {
+				t.Fatal(err)
+			}
+			if again.String() != strings.ReplaceAll(b.String(), "\r\n", "\n") {
+				t.Errorf("converting the imported collection to markdown doesn't give the same markdown")
+			}
+		})
+	}
+}
+
+func TestImportMarkdownGolden(t *testing.T) {
+	tests := []struct {
+		mdPath, tmplPath string
+	}{
+		{"../samples/calendar-API-v1.md", ""},
+		{"../samples/minimal-calendar-API-v1.md", "minimal.tmpl"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mdPath, func(t *testing.T) {
+			mdBytes, err := os.ReadFile(test.mdPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.ReplaceAll(string(mdBytes), "\r\n", "\n")
+			collection, err := importMarkdown(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			if err := renderText(collection, &b, renderOptions{tmplPaths: []string{test.tmplPath}}); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != want {
+				t.Errorf("converting the imported collection to markdown gives\n%s\nwant\n%s", b.String(), want)
+			}
+		})
+	}
+}
+
+func TestImportMarkdownNestedFolders(t *testing.T) {
+	markdown := "# API\n\n" +
+		"----------------------------------------\n\n## admin\n\n" +
+		"----------------------------------------\n\n### users\n\n" +
+		"----------------------------------------\n\n#### list users\n\nGET `/admin/users`\n\n" +
+		"----------------------------------------\n\n## health\n\nGET `/health`\n"
+	collection, err := importMarkdown(markdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ans := endpointPaths(collection["item"].([]any), "")
+	want := []string{"admin/users/list users", "health"}
+	if !reflect.DeepEqual(ans, want) {
+		t.Errorf("got endpoints %q, want %q", ans, want)
+	}
+}
+
+func TestImportMarkdownWithoutTitle(t *testing.T) {
+	if _, err := importMarkdown("no header here\n"); err == nil {
+		t.Error("importMarkdown without a title returned nil error, want non-nil error")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var markerNamePattern = regexp.MustCompile(`^[\w.-]+$`)
+
+// injectMarkers returns the start and end markers of the part of a file to replace, such
+// as "<!-- pm2md:start -->" and "<!-- pm2md:end -->", or, if a marker name is given,
+// "<!-- pm2md:start users -->" and "<!-- pm2md:end users -->".
+func injectMarkers(name string) (string, string) {
+	if len(name) == 0 {
+		return "<!-- pm2md:start -->", "<!-- pm2md:end -->"
+	}
+	return "<!-- pm2md:start " + name + " -->", "<!-- pm2md:end " + name + " -->"
+}
+
+// markerPattern returns a regular expression that matches a marker with any spacing
+// within the comment.
+func markerPattern(kind, name string) *regexp.Regexp {
+	if len(name) == 0 {
+		return regexp.MustCompile(`<!--\s*pm2md:` + kind + `\s*-->`)
+	}
+	return regexp.MustCompile(`<!--\s*pm2md:` + kind + `\s+` + regexp.QuoteMeta(name) + `\s*-->`)
+}
+
+// injectText replaces everything between a pair of markers in a document with the given
+// content, surrounded by blank lines. The markers and the rest of the document are not
+// changed, so injecting the same content again doesn't change the result. If the
+// document uses CRLF line endings, so does the injected content. An error is returned
+// unless the document has exactly one pair of the markers with the start marker first.
+func injectText(doc, content, markerName string) (string, error) {
+	start, end := injectMarkers(markerName)
+	startMatches := markerPattern("start", markerName).FindAllStringIndex(doc, -1)
+	endMatches := markerPattern("end", markerName).FindAllStringIndex(doc, -1)
+	if len(startMatches) == 0 || len(endMatches) == 0 {
+		return "", fmt.Errorf("no %s and %s markers found", start, end)
+	}
+	if len(startMatches) > 1 || len(endMatches) > 1 {
+		return "", fmt.Errorf("more than one %s or %s marker found", start, end)
+	}
+	contentStart := startMatches[0][1]
+	contentEnd := endMatches[0][0]
+	if contentEnd < contentStart {
+		return "", fmt.Errorf("the %s marker must come before the %s marker", start, end)
+	}
+
+	newline := "\n"
+	if strings.Contains(doc, "\r\n") {
+		newline = "\r\n"
+	}
+	content = strings.TrimSpace(strings.ReplaceAll(content, "\r\n", "\n"))
+	content = strings.ReplaceAll(content, "\n", newline)
+	return doc[:contentStart] + newline + newline + content + newline + newline + doc[contentEnd:], nil
+}
+
+// injectFile replaces everything between a pair of markers in the file at the given path
+// with the given content. The file is only written if its content changes. If check is
+// true, the file is never written, and an error is returned if it would change. The
+// result is reported to stderr.
+func injectFile(path, content, markerName string, check bool) error {
+	docBytes, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc := string(docBytes)
+	newDoc, err := injectText(doc, content, markerName)
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+
+	if newDoc == doc {
+		fmt.Fprintf(os.Stderr, "%q is up to date\n", path)
+		return nil
+	}
+	if check {
+		return fmt.Errorf("%q is out of date. Run the same command without --check to update it", path)
+	}
+	if err := writeFileAtomic(path, []byte(newDoc)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Updated %q\n", path)
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInjectText(t *testing.T) {
+	tests := []struct {
+		name, doc, content, marker, want string
+	}{
+		{
+			"empty markers",
+			"# Service\n\n<!-- pm2md:start --><!-- pm2md:end -->\n",
+			"# API\n",
+			"",
+			"# Service\n\n<!-- pm2md:start -->\n\n# API\n\n<!-- pm2md:end -->\n",
+		},
+		{
+			"replace old content",
+			"intro\n<!-- pm2md:start -->\nold\n<!-- pm2md:end -->\nfooter\n",
+			"new",
+			"",
+			"intro\n<!-- pm2md:start -->\n\nnew\n\n<!-- pm2md:end -->\nfooter\n",
+		},
+		{
+			"named markers",
+			"<!-- pm2md:start -->\na\n<!-- pm2md:end -->\n<!--pm2md:start users-->\nb\n<!--pm2md:end users-->\n",
+			"c",
+			"users",
+			"<!-- pm2md:start -->\na\n<!-- pm2md:end -->\n<!--pm2md:start users-->\n\nc\n\n<!--pm2md:end users-->\n",
+		},
+		{
+			"CRLF",
+			"a\r\n<!-- pm2md:start -->\r\n<!-- pm2md:end -->\r\n",
+			"# API\n\ntext\n",
+			"",
+			"a\r\n<!-- pm2md:start -->\r\n\r\n# API\r\n\r\ntext\r\n\r\n<!-- pm2md:end -->\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ans, err := injectText(test.doc, test.content, test.marker)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ans != test.want {
+				t.Errorf("injectText(%q, %q, %q) = %q, want %q", test.doc, test.content, test.marker, ans, test.want)
+			}
+			again, err := injectText(ans, test.content, test.marker)
+			if err != nil || again != ans {
+				t.Errorf("injecting again = (%q, %v), want (%q, nil)", again, err, ans)
+			}
+		})
+	}
+}
+
+func TestInjectTextWithInvalidMarkers(t *testing.T) {
+	tests := []struct {
+		name, doc, marker string
+	}{
+		{"no markers", "# Service\n", ""},
+		{"no end marker", "<!-- pm2md:start -->\n", ""},
+		{"wrong name", "<!-- pm2md:start -->\n<!-- pm2md:end -->\n", "users"},
+		{"similar name", "<!-- pm2md:start users2 -->\n<!-- pm2md:end users2 -->\n", "users"},
+		{"duplicate markers", "<!-- pm2md:start -->\n<!-- pm2md:end -->\n<!-- pm2md:start -->\n<!-- pm2md:end -->\n", ""},
+		{"end before start", "<!-- pm2md:end -->\n<!-- pm2md:start -->\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if ans, err := injectText(test.doc, "content", test.marker); err == nil {
+				t.Errorf("injectText(%q, \"content\", %q) = (%q, nil), want non-nil error", test.doc, test.marker, ans)
+			}
+		})
+	}
+}
+
+func TestInjectFileCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "README.md")
+	doc := "# Service\n\n<!-- pm2md:start -->\n<!-- pm2md:end -->\n"
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := injectFile(path, "# API", "", true); err == nil {
+		t.Error("checking an out-of-date file returned nil error, want non-nil error")
+	}
+	if docBytes, _ := os.ReadFile(path); string(docBytes) != doc {
+		t.Errorf("checking changed the file to %q", string(docBytes))
+	}
+	if err := injectFile(path, "# API", "", false); err != nil {
+		t.Error(err)
+	}
+	if err := injectFile(path, "# API", "", true); err != nil {
+		t.Errorf("checking an up-to-date file returned %v, want nil", err)
+	}
+}
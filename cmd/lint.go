@@ -0,0 +1,265 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// severities are the severities of lint problems from least to most severe. A rule
+// with the severity "off" is not checked.
+var severities = []string{"info", "warning", "error"}
+
+// lintRule is a check for a documentation gap in a collection.
+type lintRule struct {
+	id          string
+	severity    string
+	description string
+}
+
+var lintRules = []lintRule{
+	{"missing-description", "warning", "Folders and endpoints should have descriptions."},
+	{"no-examples", "warning", "Endpoints should have at least one saved sample response."},
+	{"empty-example-body", "info", "Sample responses should have bodies unless their status means no content."},
+	{"no-success-example", "warning", "Endpoints with sample responses should have at least one with a 2xx status."},
+	{"duplicate-name", "error", "Items with the same name have header links that are easy to confuse."},
+	{"undefined-variable", "warning", "Each {{variable}} should be defined in the collection or the environment."},
+}
+
+// lintProblem is a documentation gap found by a lint rule.
+type lintProblem struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+
+	// Item is the path of the folder or endpoint with the problem, such as
+	// "users / create user", or empty for the whole collection.
+	Item    string `json:"item"`
+	Message string `json:"message"`
+}
+
+// lintOptions are the settings for linting a collection.
+type lintOptions struct {
+	// severities are the severities of the rules by rule ID. Rules not in the map have
+	// their default severities.
+	severities map[string]string
+
+	// style is the anchor style used to find duplicate header links.
+	style anchorStyle
+
+	// vars are the names of variables defined outside the collection, such as in an
+	// environment.
+	vars map[string]string
+}
+
+// parseRuleSeverities validates rule severities such as {"no-examples": "error"}.
+func parseRuleSeverities(ruleSeverities map[string]string) (map[string]string, error) {
+	result := make(map[string]string, len(ruleSeverities))
+	for id, severity := range ruleSeverities {
+		if !slices.ContainsFunc(lintRules, func(r lintRule) bool { return r.id == id }) {
+			return nil, fmt.Errorf("unknown lint rule %q. The lint rules are: %s", id, strings.Join(lintRuleIDs(), ", "))
+		}
+		severity = strings.ToLower(severity)
+		if severity != "off" && !slices.Contains(severities, severity) {
+			return nil, fmt.Errorf(
+				"unknown severity %q for lint rule %q. The severities are: off, %s",
+				severity, id, strings.Join(severities, ", "),
+			)
+		}
+		result[id] = severity
+	}
+	return result, nil
+}
+
+// lintRuleIDs returns the IDs of all the lint rules.
+func lintRuleIDs() []string {
+	ids := make([]string, len(lintRules))
+	for i, rule := range lintRules {
+		ids[i] = rule.id
+	}
+	return ids
+}
+
+// severityRank returns the position of a severity in severities, or -1 if the severity
+// is unknown or "off".
+func severityRank(severity string) int {
+	return slices.Index(severities, severity)
+}
+
+// linter collects the problems in one collection.
+type linter struct {
+	opts     lintOptions
+	problems []lintProblem
+
+	// headerPaths are the items with each header link path.
+	headerPaths map[string][]string
+
+	// definedVars are the names of the variables defined in the collection or in
+	// opts.vars.
+	definedVars map[string]bool
+}
+
+// lintCollection checks a collection for documentation gaps and returns the problems
+// found in the order of the collection's items.
+func lintCollection(collection map[string]any, opts lintOptions) []lintProblem {
+	l := linter{
+		opts:        opts,
+		problems:    make([]lintProblem, 0),
+		headerPaths: make(map[string][]string),
+		definedVars: make(map[string]bool),
+	}
+	for name := range opts.vars {
+		l.definedVars[name] = true
+	}
+	if variables, ok := collection["variable"].([]any); ok {
+		for _, variableAny := range variables {
+			if variable, ok := variableAny.(map[string]any); ok {
+				l.definedVars[fmt.Sprint(variable["key"])] = true
+			}
+		}
+	}
+
+	if info, ok := collection["info"].(map[string]any); ok {
+		name := fmt.Sprint(info["name"])
+		l.headerPaths[opts.style.formatPath(name)] = []string{name}
+	}
+	items, _ := collection["item"].([]any)
+	l.lintItems(items, "")
+
+	return l.problems
+}
+
+// report adds a problem unless its rule is off.
+func (l *linter) report(ruleID, item, message string) {
+	severity, ok := l.opts.severities[ruleID]
+	if !ok {
+		i := slices.IndexFunc(lintRules, func(r lintRule) bool { return r.id == ruleID })
+		severity = lintRules[i].severity
+	}
+	if severity == "off" {
+		return
+	}
+	l.problems = append(l.problems, lintProblem{ruleID, severity, item, message})
+}
+
+func (l *linter) lintItems(items []any, namePrefix string) {
+	for _, itemAny := range items {
+		item := itemAny.(map[string]any)
+		name := fmt.Sprint(item["name"])
+		itemPath := namePrefix + name
+		l.lintHeaderPath(name, itemPath)
+
+		subItemsAny, isFolder := item["item"]
+		if isFolder {
+			if len(strings.TrimSpace(descriptionText(item["description"]))) == 0 {
+				l.report("missing-description", itemPath, "folder has no description")
+			}
+			l.lintItems(subItemsAny.([]any), itemPath+" / ")
+			continue
+		}
+
+		request, _ := item["request"].(map[string]any)
+		if len(strings.TrimSpace(descriptionText(item["description"])+descriptionText(request["description"]))) == 0 {
+			l.report("missing-description", itemPath, "endpoint has no description")
+		}
+		l.lintVariables(item, itemPath)
+
+		responses, _ := item["response"].([]any)
+		if len(responses) == 0 {
+			l.report("no-examples", itemPath, "endpoint has no sample responses")
+			continue
+		}
+		hasSuccess := false
+		for i, responseAny := range responses {
+			response, _ := responseAny.(map[string]any)
+			code, _ := response["code"].(float64)
+			if code >= 200 && code <= 299 {
+				hasSuccess = true
+			}
+			body, _ := response["body"].(string)
+			if len(strings.TrimSpace(body)) == 0 && !statusHasNoContent(int(code)) {
+				l.report(
+					"empty-example-body",
+					itemPath,
+					fmt.Sprintf("sample response %d (%q) has no body", i+1, fmt.Sprint(response["name"])),
+				)
+			}
+		}
+		if !hasSuccess {
+			l.report("no-success-example", itemPath, "endpoint has no sample response with a 2xx status")
+		}
+	}
+}
+
+// lintHeaderPath reports an item whose header link path is the same as an earlier
+// header's path before a number is added to make it unique.
+func (l *linter) lintHeaderPath(name, itemPath string) {
+	headerPath := l.opts.style.formatPath(name)
+	if earlier := l.headerPaths[headerPath]; len(earlier) > 0 {
+		l.report(
+			"duplicate-name",
+			itemPath,
+			fmt.Sprintf("header link %q is also used by %q", headerPath, earlier[0]),
+		)
+	}
+	l.headerPaths[headerPath] = append(l.headerPaths[headerPath], itemPath)
+}
+
+// lintVariables reports each variable used in an endpoint's request or in the original
+// requests of its sample responses that is not defined, once per endpoint. Postman's
+// dynamic variables such as `{{$guid}}` are always defined.
+func (l *linter) lintVariables(endpoint map[string]any, itemPath string) {
+	reported := make(map[string]bool)
+	var walk func(value any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case string:
+			for _, match := range variablePattern.FindAllStringSubmatch(v, -1) {
+				name := match[1]
+				if strings.HasPrefix(name, "$") || l.definedVars[name] || reported[name] {
+					continue
+				}
+				reported[name] = true
+				l.report("undefined-variable", itemPath, fmt.Sprintf("variable %q is not defined", name))
+			}
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			for _, key := range keys {
+				walk(v[key])
+			}
+		case []any:
+			for _, subValue := range v {
+				walk(subValue)
+			}
+		}
+	}
+	walk(endpoint["request"])
+	responses, _ := endpoint["response"].([]any)
+	for _, responseAny := range responses {
+		if response, ok := responseAny.(map[string]any); ok {
+			walk(response["originalRequest"])
+		}
+	}
+}
+
+// statusHasNoContent reports whether responses with the given status never have bodies.
+func statusHasNoContent(code int) bool {
+	return (code >= 100 && code <= 199) || code == 204 || code == 304
+}
@@ -0,0 +1,196 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var lintReportFormats = []string{"text", "json", "sarif"}
+
+var LintReport string
+var LintFailOn string
+var LintRules map[string]string
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [collection.json...]",
+	Short: "Report documentation gaps in collections",
+	Long: "Report documentation gaps in collections\n\n" +
+		"The rules are:\n" + lintRulesHelp() + "\n" +
+		"Each rule's severity can be changed, or the rule turned off, with --rule. The\n" +
+		"command fails if any problem is at or above the --fail-on severity.",
+	Example: `  pm2md lint collection.json
+  pm2md lint collection.json --rule=missing-description=off --rule=no-examples=error
+  pm2md lint collection.json --report=sarif --fail-on=warning > lint.sarif`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: lintRunFunc,
+}
+
+// lintRulesHelp lists the lint rules with their default severities and descriptions.
+func lintRulesHelp() string {
+	var b strings.Builder
+	for _, rule := range lintRules {
+		fmt.Fprintf(&b, "  %-20s %-8s %s\n", rule.id, rule.severity, rule.description)
+	}
+	return b.String()
+}
+
+// lintResult is the problems found in one collection.
+type lintResult struct {
+	Input    string        `json:"input"`
+	Problems []lintProblem `json:"problems"`
+}
+
+// lintRunFunc lints each chosen collection, prints a report, and returns an error if any
+// problem is at or above the chosen severity.
+func lintRunFunc(cmd *cobra.Command, args []string) error {
+	ruleSeverities, err := parseRuleSeverities(LintRules)
+	if err != nil {
+		return err
+	}
+	if LintFailOn != "none" && severityRank(LintFailOn) < 0 {
+		return fmt.Errorf("unknown severity %q. The --fail-on choices are: none, %s", LintFailOn, strings.Join(severities, ", "))
+	}
+	if !slices.Contains(lintReportFormats, LintReport) {
+		return fmt.Errorf("unknown report format %q. The report formats are: %s", LintReport, strings.Join(lintReportFormats, ", "))
+	}
+	style, err := getAnchorStyle(AnchorStyle)
+	if err != nil {
+		return err
+	}
+	opts := lintOptions{severities: ruleSeverities, style: style}
+	if len(EnvFilePath) > 0 {
+		opts.vars, err = loadEnvironment(EnvFilePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	results := make([]lintResult, len(args))
+	for i, inputPath := range args {
+		collection, err := readSelectedCollection(inputPath, Collections, "")
+		if err != nil {
+			return fmt.Errorf("%s: %s", inputPath, err)
+		}
+		results[i] = lintResult{inputPath, lintCollection(collection, opts)}
+	}
+
+	switch LintReport {
+	case "json":
+		err = writeLintJSON(os.Stdout, results)
+	case "sarif":
+		err = writeLintSARIF(os.Stdout, results)
+	default:
+		writeLintText(os.Stdout, results)
+	}
+	if err != nil {
+		return err
+	}
+
+	if LintFailOn == "none" {
+		return nil
+	}
+	failCount := 0
+	for _, result := range results {
+		for _, problem := range result.Problems {
+			if severityRank(problem.Severity) >= severityRank(LintFailOn) {
+				failCount++
+			}
+		}
+	}
+	if failCount > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d problems at or above severity %q", failCount, LintFailOn)
+	}
+	return nil
+}
+
+// writeLintText writes one line per problem, such as
+// `api.json: warning: users / create user: endpoint has no description [missing-description]`.
+func writeLintText(w io.Writer, results []lintResult) {
+	for _, result := range results {
+		for _, p := range result.Problems {
+			message := p.Message
+			if len(p.Item) > 0 {
+				message = p.Item + ": " + message
+			}
+			fmt.Fprintf(w, "%s: %s: %s [%s]\n", result.Input, p.Severity, message, p.Rule)
+		}
+	}
+}
+
+// writeLintJSON writes the results as a JSON list with one object per collection.
+func writeLintJSON(w io.Writer, results []lintResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+// writeLintSARIF writes the results in the Static Analysis Results Interchange Format
+// (SARIF) 2.1.0, which code scanning tools such as GitHub's can show.
+func writeLintSARIF(w io.Writer, results []lintResult) error {
+	type object = map[string]any
+	sarifLevels := map[string]string{"info": "note", "warning": "warning", "error": "error"}
+
+	rules := make([]any, len(lintRules))
+	for i, rule := range lintRules {
+		rules[i] = object{
+			"id":                   rule.id,
+			"shortDescription":     object{"text": rule.description},
+			"defaultConfiguration": object{"level": sarifLevels[rule.severity]},
+		}
+	}
+	sarifResults := make([]any, 0)
+	for _, result := range results {
+		for _, p := range result.Problems {
+			location := object{
+				"physicalLocation": object{"artifactLocation": object{"uri": result.Input}},
+			}
+			if len(p.Item) > 0 {
+				location["logicalLocations"] = []any{object{"fullyQualifiedName": p.Item}}
+			}
+			sarifResults = append(sarifResults, object{
+				"ruleId":    p.Rule,
+				"ruleIndex": slices.Index(lintRuleIDs(), p.Rule),
+				"level":     sarifLevels[p.Severity],
+				"message":   object{"text": p.Message},
+				"locations": []any{location},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(object{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{object{
+			"tool": object{"driver": object{
+				"name":           "pm2md",
+				"version":        strings.Fields(version)[0],
+				"informationUri": "https://github.com/wheelercj/pm2md",
+				"rules":          rules,
+			}},
+			"results": sarifResults,
+		}},
+	})
+}
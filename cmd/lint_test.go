@@ -0,0 +1,177 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// newLintTestCollection creates a collection with one of each kind of lint problem.
+func newLintTestCollection() map[string]any {
+	return map[string]any{
+		"info":     map[string]any{"name": "API"},
+		"variable": []any{map[string]any{"key": "base_url", "value": "https://example.com"}},
+		"item": []any{
+			map[string]any{
+				"name":        "users",
+				"description": "User accounts.",
+				"item": []any{
+					map[string]any{
+						"name": "list users",
+						"request": map[string]any{
+							"description": "Lists users.",
+							"url":         "{{base_url}}/users?key={{api_key}}&id={{$guid}}",
+							"header":      []any{map[string]any{"key": "X-Key", "value": "{{api_key}}"}},
+						},
+						"response": []any{
+							map[string]any{
+								"name":            "ok",
+								"code":            float64(200),
+								"body":            "[]",
+								"originalRequest": map[string]any{"url": "{{base_url}}/users?key={{api_key}}"},
+							},
+							map[string]any{"name": "no content", "code": float64(204), "body": ""},
+							map[string]any{"name": "bad", "code": float64(400), "body": ""},
+						},
+					},
+					map[string]any{
+						"name":     "delete user",
+						"request":  map[string]any{"description": "Deletes a user.", "url": "{{base_url}}/users"},
+						"response": []any{},
+					},
+				},
+			},
+			map[string]any{
+				"name": "admin",
+				"item": []any{
+					map[string]any{
+						"name":    "List Users",
+						"request": map[string]any{"url": "{{base_url}}/admin/users"},
+						"response": []any{
+							map[string]any{
+								"name":            "error",
+								"code":            float64(500),
+								"body":            "{}",
+								"originalRequest": map[string]any{"url": "{{base_url}}/admin/users?tenant={{tenant}}"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestLintCollection(t *testing.T) {
+	style, _ := getAnchorStyle("")
+	problems := lintCollection(newLintTestCollection(), lintOptions{style: style})
+	want := []lintProblem{
+		{"undefined-variable", "warning", "users / list users", `variable "api_key" is not defined`},
+		{"empty-example-body", "info", "users / list users", `sample response 3 ("bad") has no body`},
+		{"no-examples", "warning", "users / delete user", "endpoint has no sample responses"},
+		{"missing-description", "warning", "admin", "folder has no description"},
+		{"duplicate-name", "error", "admin / List Users", `header link "#list-users" is also used by "users / list users"`},
+		{"missing-description", "warning", "admin / List Users", "endpoint has no description"},
+		{"undefined-variable", "warning", "admin / List Users", `variable "tenant" is not defined`},
+		{"no-success-example", "warning", "admin / List Users", "endpoint has no sample response with a 2xx status"},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("got problems\n%v\nwant\n%v", problems, want)
+	}
+}
+
+func TestLintCollectionWithOptions(t *testing.T) {
+	severities, err := parseRuleSeverities(map[string]string{
+		"missing-description": "off",
+		"empty-example-body":  "off",
+		"no-success-example":  "off",
+		"duplicate-name":      "off",
+		"no-examples":         "ERROR",
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	style, _ := getAnchorStyle("")
+	opts := lintOptions{severities: severities, style: style, vars: map[string]string{"api_key": "abc", "tenant": "acme"}}
+	problems := lintCollection(newLintTestCollection(), opts)
+	want := []lintProblem{
+		{"no-examples", "error", "users / delete user", "endpoint has no sample responses"},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("got problems %v, want %v", problems, want)
+	}
+}
+
+func TestParseRuleSeveritiesWithInvalidInput(t *testing.T) {
+	tests := []map[string]string{
+		{"no-description": "warning"},
+		{"no-examples": "fatal"},
+	}
+
+	for _, test := range tests {
+		if _, err := parseRuleSeverities(test); err == nil {
+			t.Errorf("parseRuleSeverities(%v) returned nil error, want non-nil error", test)
+		}
+	}
+}
+
+func TestWriteLintText(t *testing.T) {
+	results := []lintResult{{"api.json", []lintProblem{
+		{"no-examples", "warning", "users / delete user", "endpoint has no sample responses"},
+	}}}
+	var b bytes.Buffer
+	writeLintText(&b, results)
+	want := "api.json: warning: users / delete user: endpoint has no sample responses [no-examples]\n"
+	if b.String() != want {
+		t.Errorf("writeLintText(...) wrote %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteLintSARIF(t *testing.T) {
+	results := []lintResult{{"api.json", []lintProblem{
+		{"empty-example-body", "info", "users / list users", "sample response 1 has no body"},
+	}}}
+	var b bytes.Buffer
+	if err := writeLintSARIF(&b, results); err != nil {
+		t.Error(err)
+		return
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &log); err != nil {
+		t.Error(err)
+		return
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Errorf("unexpected SARIF log: %s", b.String())
+		return
+	}
+	result := log.Runs[0].Results[0]
+	if result.RuleID != "empty-example-body" || result.RuleIndex != 2 || result.Level != "note" {
+		t.Errorf("got result %+v, want rule empty-example-body at index 2 with level note", result)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+const collectionSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// mergeCollections combines collections into one collection with the given title. Each
+// collection becomes a top-level folder with the collection's name, description, auth,
+// and events. If a collection has a variable prefix, the prefix is added to the name of
+// each of its variables and to each `{{variable}}` in it, except Postman's dynamic
+// variables such as `{{$guid}}`. The merged collection's variables are all of the
+// collections' variables. If the title is empty, the collections' names are used.
+func mergeCollections(collections []map[string]any, prefixes []string, title string) map[string]any {
+	items := make([]any, len(collections))
+	variables := make([]any, 0)
+	names := make([]string, len(collections))
+	for i, collection := range collections {
+		if len(prefixes[i]) > 0 {
+			prefixVariables(collection, prefixes[i])
+		}
+		info, _ := collection["info"].(map[string]any)
+		names[i] = fmt.Sprint(info["name"])
+		folder := map[string]any{
+			"name": names[i],
+			"item": collection["item"],
+		}
+		if folder["item"] == nil {
+			folder["item"] = []any{}
+		}
+		if description, ok := info["description"]; ok {
+			folder["description"] = description
+		}
+		for _, key := range []string{"auth", "event"} {
+			if value, ok := collection[key]; ok {
+				folder[key] = value
+			}
+		}
+		items[i] = folder
+		if vars, ok := collection["variable"].([]any); ok {
+			variables = append(variables, vars...)
+		}
+	}
+	if len(title) == 0 {
+		title = strings.Join(names, ", ")
+	}
+
+	return map[string]any{
+		"info": map[string]any{
+			"name":   title,
+			"schema": collectionSchema,
+		},
+		"item":     items,
+		"variable": variables,
+	}
+}
+
+// prefixVariables adds a prefix to the name of each of a collection's variables and to
+// each `{{variable}}` in the collection, except Postman's dynamic variables such as
+// `{{$guid}}`.
+func prefixVariables(collection map[string]any, prefix string) {
+	if vars, ok := collection["variable"].([]any); ok {
+		for _, variableAny := range vars {
+			if variable, ok := variableAny.(map[string]any); ok {
+				variable["key"] = prefix + fmt.Sprint(variable["key"])
+			}
+		}
+	}
+	for key, value := range collection {
+		if key != "info" && key != "variable" {
+			collection[key] = _prefixVariables(value, prefix)
+		}
+	}
+}
+
+func _prefixVariables(value any, prefix string) any {
+	switch v := value.(type) {
+	case string:
+		return variablePattern.ReplaceAllStringFunc(v, func(match string) string {
+			name := match[2 : len(match)-2]
+			if strings.HasPrefix(name, "$") {
+				return match
+			}
+			return "{{" + prefix + name + "}}"
+		})
+	case map[string]any:
+		for key, subValue := range v {
+			v[key] = _prefixVariables(subValue, prefix)
+		}
+	case []any:
+		for i, subValue := range v {
+			v[i] = _prefixVariables(subValue, prefix)
+		}
+	}
+	return value
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+var MergeTitle string
+var MergeOutput string
+var MergePrefixes map[string]string
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [collection.json...]",
+	Short: "Convert several collections to one document",
+	Long: "Convert several collections to one document\n\n" +
+		"Each collection becomes a top-level section with the collection's name. Header links\n" +
+		"are unique across all the collections. A variable prefix for a collection is added to\n" +
+		"each of its {{variables}} so that variables with the same name in different\n" +
+		"collections, such as {{base_url}}, can have different values in an environment.",
+	Example: `  pm2md merge users.json orders.json --title="Developer portal" --output=portal.md
+  pm2md merge users.json orders.json --prefix=users.json=users_ --prefix=orders.json=orders_ --env=prod.json`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: mergeRunFunc,
+}
+
+// mergeRunFunc merges the chosen collections and converts them to one document.
+func mergeRunFunc(cmd *cobra.Command, args []string) error {
+	inputs := make([]MergeInput, len(args))
+	for i, arg := range args {
+		inputs[i] = MergeInput{Path: arg, Prefix: MergePrefixes[arg]}
+	}
+	for path := range MergePrefixes {
+		if !slices.Contains(args, path) {
+			return fmt.Errorf("the variable prefix for %q is for a collection that isn't being merged", path)
+		}
+	}
+	if len(MergeOutput) > 0 && len(Inject) > 0 {
+		return fmt.Errorf("choose either an output file or a file to inject into, not both")
+	}
+	target := flagTarget().withOverrides(Target{
+		Inputs: inputs,
+		Title:  MergeTitle,
+		Output: MergeOutput,
+	})
+	if err := target.validate(); err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+	destPath, err := generateTarget(target)
+	if err != nil {
+		return err
+	}
+	if destPath != "-" && len(target.Inject) == 0 {
+		fmt.Fprintf(os.Stderr, "Created %q\n", destPath)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newMergeTestCollection creates a collection with one endpoint that uses a variable.
+func newMergeTestCollection(name string) map[string]any {
+	return map[string]any{
+		"info": map[string]any{"name": name, "description": name + " service", "schema": collectionSchema},
+		"auth": map[string]any{"type": "bearer"},
+		"variable": []any{
+			map[string]any{"key": "base_url", "value": "https://" + name + ".example.com"},
+		},
+		"item": []any{
+			map[string]any{
+				"name": "list things",
+				"request": map[string]any{
+					"method": "GET",
+					"url":    map[string]any{"raw": "{{base_url}}/things?id={{$guid}}"},
+				},
+				"response": []any{},
+			},
+		},
+	}
+}
+
+func TestMergeCollections(t *testing.T) {
+	merged := mergeCollections(
+		[]map[string]any{newMergeTestCollection("users"), newMergeTestCollection("orders")},
+		[]string{"users_", ""},
+		"Portal",
+	)
+
+	info := merged["info"].(map[string]any)
+	if info["name"] != "Portal" || info["schema"] != collectionSchema {
+		t.Errorf("got info %v, want the name \"Portal\" and the v2.1 schema", info)
+	}
+	items := merged["item"].([]any)
+	if len(items) != 2 {
+		t.Fatalf("got %d top-level items, want 2", len(items))
+	}
+	users := items[0].(map[string]any)
+	if users["name"] != "users" || users["description"] != "users service" || users["auth"] == nil {
+		t.Errorf("got first folder %v, want the users collection's name, description, and auth", users)
+	}
+
+	urls := make([]string, 0)
+	for _, folderAny := range items {
+		endpoint := folderAny.(map[string]any)["item"].([]any)[0].(map[string]any)
+		urls = append(urls, endpoint["request"].(map[string]any)["url"].(map[string]any)["raw"].(string))
+	}
+	wantURLs := []string{"{{users_base_url}}/things?id={{$guid}}", "{{base_url}}/things?id={{$guid}}"}
+	if !reflect.DeepEqual(urls, wantURLs) {
+		t.Errorf("got URLs %q, want %q", urls, wantURLs)
+	}
+
+	keys := make([]string, 0)
+	for _, variableAny := range merged["variable"].([]any) {
+		keys = append(keys, variableAny.(map[string]any)["key"].(string))
+	}
+	wantKeys := []string{"users_base_url", "base_url"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("got variables %q, want %q", keys, wantKeys)
+	}
+}
+
+func TestMergeCollectionsDefaultTitle(t *testing.T) {
+	merged := mergeCollections(
+		[]map[string]any{newMergeTestCollection("users"), newMergeTestCollection("orders")},
+		[]string{"", ""},
+		"",
+	)
+	if name := merged["info"].(map[string]any)["name"]; name != "users, orders" {
+		t.Errorf("got title %q, want %q", name, "users, orders")
+	}
+}
+
+func TestMergeCollectionsUniqueLinks(t *testing.T) {
+	merged := mergeCollections(
+		[]map[string]any{newMergeTestCollection("api"), newMergeTestCollection("api")},
+		[]string{"", ""},
+		"Portal",
+	)
+	style, _ := getAnchorStyle("")
+	addLevelProperty(merged)
+	addTableOfContents(merged, style, tocOptions{}, headingStrategy{})
+	links := make([]string, 0)
+	for _, entryAny := range merged["toc"].([]any) {
+		links = append(links, entryAny.(map[string]any)["link"].(string))
+	}
+	want := []string{"#api", "#list-things", "#api-1", "#list-things-1"}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("got links %q, want %q", links, want)
+	}
+}
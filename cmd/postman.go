@@ -0,0 +1,238 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultPostmanAPIURL = "https://api.getpostman.com"
+const defaultAPIKeyEnv = "POSTMAN_API_KEY"
+
+// userCacheDir returns the folder for the user's cached data. Tests replace it.
+var userCacheDir = os.UserCacheDir
+
+// postmanClient fetches collections and environments from the Postman API. Responses
+// with an ETag are cached, and a cached response is used again if the API says it
+// hasn't changed.
+type postmanClient struct {
+	baseURL   string
+	apiKey    string
+	apiKeyEnv string
+
+	// cacheDir is the folder of cached responses. If empty, nothing is cached.
+	cacheDir string
+
+	http *http.Client
+}
+
+// newPostmanClient creates a Postman API client with the API URL and the environment
+// variable of the API key chosen in a target, or their defaults. Responses are cached in
+// the user's cache folder.
+func newPostmanClient(target Target) (*postmanClient, error) {
+	apiKeyEnv := target.APIKeyEnv
+	if len(apiKeyEnv) == 0 {
+		apiKeyEnv = defaultAPIKeyEnv
+	}
+	apiKey := strings.TrimSpace(os.Getenv(apiKeyEnv))
+	if len(apiKey) == 0 {
+		return nil, fmt.Errorf(
+			"the environment variable %s is empty. Set it to a Postman API key, which you can create in Postman's settings",
+			apiKeyEnv,
+		)
+	}
+	baseURL := target.APIURL
+	if len(baseURL) == 0 {
+		baseURL = defaultPostmanAPIURL
+	}
+	var cacheDir string
+	if dir, err := userCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "pm2md", "postman")
+	}
+
+	return &postmanClient{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		apiKey:    apiKey,
+		apiKeyEnv: apiKeyEnv,
+		cacheDir:  cacheDir,
+		http:      &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// fetchCollection fetches the collection with the given UID.
+func (c *postmanClient) fetchCollection(uid string) (map[string]any, error) {
+	body, err := c.get("collection", uid)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Collection json.RawMessage `json:"collection"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid response from the Postman API: %s", err)
+	}
+	if response.Collection == nil {
+		return nil, fmt.Errorf("invalid response from the Postman API: no collection found")
+	}
+	return parseCollection(response.Collection)
+}
+
+// fetchEnvironment fetches the environment with the given UID and returns a map from
+// each enabled variable's name to its value.
+func (c *postmanClient) fetchEnvironment(uid string) (map[string]string, error) {
+	body, err := c.get("environment", uid)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Environment json.RawMessage `json:"environment"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid response from the Postman API: %s", err)
+	}
+	if response.Environment == nil {
+		return nil, fmt.Errorf("invalid response from the Postman API: no environment found")
+	}
+	return parseEnvironment(response.Environment)
+}
+
+// get fetches a collection or environment, which is the kind, and returns the body of
+// the response. If the body is cached and hasn't changed, the cached body is returned.
+func (c *postmanClient) get(kind, uid string) ([]byte, error) {
+	endpoint := c.baseURL + "/" + kind + "s/" + url.PathEscape(uid)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Api-Key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "pm2md")
+
+	cachePath := c.cachePath(endpoint)
+	cachedBody, etag := c.readCache(cachePath)
+	if len(etag) > 0 {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't reach the Postman API: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && len(etag) > 0 {
+		return cachedBody, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the response from the Postman API: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.apiError(kind, uid, resp.StatusCode, body)
+	}
+	if etag := resp.Header.Get("ETag"); len(etag) > 0 {
+		c.writeCache(cachePath, body, etag)
+	}
+	return body, nil
+}
+
+// apiError describes an unsuccessful response from the Postman API.
+func (c *postmanClient) apiError(kind, uid string, statusCode int, body []byte) error {
+	var response struct {
+		Error struct {
+			Name    string `json:"name"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &response)
+	message := response.Error.Message
+	if len(message) == 0 {
+		message = http.StatusText(statusCode)
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf(
+			"the Postman API rejected the API key in %s (401 Unauthorized: %s). Check that the key is correct and hasn't expired",
+			c.apiKeyEnv, message,
+		)
+	case http.StatusForbidden:
+		return fmt.Errorf(
+			"the API key in %s isn't allowed to access %s %q (403 Forbidden: %s)",
+			c.apiKeyEnv, kind, uid, message,
+		)
+	case http.StatusNotFound:
+		return fmt.Errorf(
+			"%s %q not found (404 Not Found: %s). Use the %s's UID, which you can find in its info panel in Postman",
+			kind, uid, message, kind,
+		)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("the Postman API's rate limit was reached (429 Too Many Requests: %s). Try again later", message)
+	}
+	return fmt.Errorf("the Postman API returned %d %s: %s", statusCode, http.StatusText(statusCode), message)
+}
+
+// cachePath returns the path of the cached response for a URL, or an empty string if
+// responses aren't cached.
+func (c *postmanClient) cachePath(endpoint string) string {
+	if len(c.cacheDir) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(endpoint))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// readCache returns a cached response body and its ETag, or empty values if there is no
+// cached response.
+func (c *postmanClient) readCache(cachePath string) ([]byte, string) {
+	if len(cachePath) == 0 {
+		return nil, ""
+	}
+	etag, err := os.ReadFile(cachePath + ".etag")
+	if err != nil {
+		return nil, ""
+	}
+	body, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, ""
+	}
+	return body, string(etag)
+}
+
+// writeCache saves a response body and its ETag. Caching is only an optimization, so
+// failures are ignored.
+func (c *postmanClient) writeCache(cachePath string, body []byte, etag string) {
+	if len(cachePath) == 0 {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o700); err != nil {
+		return
+	}
+	os.Remove(cachePath + ".etag")
+	if err := writeFileAtomic(cachePath, body); err != nil {
+		return
+	}
+	writeFileAtomic(cachePath+".etag", []byte(etag))
+}
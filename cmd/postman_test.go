@@ -0,0 +1,192 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAPIKey = "PMAK-test-key"
+
+// mockPostmanAPI is a stand-in for the Postman API that serves the sample collection and
+// an environment, and counts its requests.
+type mockPostmanAPI struct {
+	requests    int
+	notModified int
+}
+
+func (m *mockPostmanAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.requests++
+	if r.Header.Get("X-Api-Key") != testAPIKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"name":"AuthenticationError","message":"Invalid API Key. Every request requires a valid API Key to be sent."}}`))
+		return
+	}
+
+	var body []byte
+	switch r.URL.Path {
+	case "/collections/12345-abc":
+		collectionBytes, err := os.ReadFile("../samples/calendar-API.postman_collection.json")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ = json.Marshal(map[string]json.RawMessage{"collection": collectionBytes})
+	case "/environments/12345-env":
+		body = []byte(`{"environment":{"id":"env","name":"dev","values":[{"key":"base_url","value":"https://dev.example.com","enabled":true}]}}`)
+	case "/collections/forbidden":
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"name":"forbiddenError","message":"You are not permitted to perform the action."}}`))
+		return
+	case "/collections/busy":
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	case "/collections/broken":
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":{"name":"serverError","message":"Something went wrong."}}`))
+		return
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"name":"instanceNotFoundError","message":"We could not find the collection you are looking for"}}`))
+		return
+	}
+
+	etag := `"v1"`
+	if r.Header.Get("If-None-Match") == etag {
+		m.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Write(body)
+}
+
+// newTestPostmanClient starts a mock Postman API and returns a client for it that caches
+// in a temporary folder.
+func newTestPostmanClient(t *testing.T, apiKey string) (*postmanClient, *mockPostmanAPI) {
+	t.Helper()
+	api := &mockPostmanAPI{}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	cacheDir := t.TempDir()
+	originalUserCacheDir := userCacheDir
+	userCacheDir = func() (string, error) { return cacheDir, nil }
+	t.Cleanup(func() { userCacheDir = originalUserCacheDir })
+	t.Setenv("TEST_POSTMAN_API_KEY", apiKey)
+
+	client, err := newPostmanClient(Target{APIKeyEnv: "TEST_POSTMAN_API_KEY", APIURL: server.URL + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, api
+}
+
+func TestFetchCollectionWithCache(t *testing.T) {
+	client, api := newTestPostmanClient(t, testAPIKey)
+
+	for i := 0; i < 2; i++ {
+		collection, err := client.fetchCollection("12345-abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := collectionName(collection); name != "calendar API" {
+			t.Errorf("fetch %d: collection name = %q, want %q", i+1, name, "calendar API")
+		}
+	}
+	if api.requests != 2 || api.notModified != 1 {
+		t.Errorf("API got %d requests with %d not modified responses, want 2 requests with 1 not modified response", api.requests, api.notModified)
+	}
+}
+
+func TestFetchEnvironment(t *testing.T) {
+	client, _ := newTestPostmanClient(t, testAPIKey)
+
+	vars, err := client.fetchEnvironment("12345-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars["base_url"] != "https://dev.example.com" {
+		t.Errorf("fetchEnvironment variables = %v, want base_url = %q", vars, "https://dev.example.com")
+	}
+}
+
+func TestFetchCollectionErrors(t *testing.T) {
+	tests := []struct {
+		name, apiKey, uid, wantErr string
+	}{
+		{"unauthorized", "PMAK-wrong-key", "12345-abc", "rejected the API key in TEST_POSTMAN_API_KEY (401 Unauthorized: Invalid API Key."},
+		{"forbidden", testAPIKey, "forbidden", "isn't allowed to access collection \"forbidden\" (403 Forbidden"},
+		{"not found", testAPIKey, "missing", "collection \"missing\" not found (404 Not Found: We could not find"},
+		{"rate limited", testAPIKey, "busy", "rate limit was reached (429 Too Many Requests: Too Many Requests)"},
+		{"server error", testAPIKey, "broken", "returned 500 Internal Server Error: Something went wrong."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, _ := newTestPostmanClient(t, test.apiKey)
+			_, err := client.fetchCollection(test.uid)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("fetchCollection(%q) error = %v, want an error containing %q", test.uid, err, test.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), test.apiKey) {
+				t.Errorf("fetchCollection(%q) error contains the API key: %v", test.uid, err)
+			}
+		})
+	}
+}
+
+func TestNewPostmanClientWithoutAPIKey(t *testing.T) {
+	t.Setenv("TEST_POSTMAN_API_KEY", "")
+	_, err := newPostmanClient(Target{APIKeyEnv: "TEST_POSTMAN_API_KEY"})
+	if err == nil || !strings.Contains(err.Error(), "TEST_POSTMAN_API_KEY is empty") {
+		t.Errorf("newPostmanClient without an API key returned error %v, want an error naming TEST_POSTMAN_API_KEY", err)
+	}
+}
+
+func TestGenerateTargetFromPostmanAPI(t *testing.T) {
+	client, _ := newTestPostmanClient(t, testAPIKey)
+	outputPath := filepath.Join(t.TempDir(), "calendar.md")
+
+	_, err := generateTarget(Target{
+		CollectionID:  "12345-abc",
+		EnvironmentID: "12345-env",
+		APIKeyEnv:     "TEST_POSTMAN_API_KEY",
+		APIURL:        client.baseURL,
+		Output:        outputPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantBytes, err := os.ReadFile("../samples/calendar-API-v1.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotBytes, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.ReplaceAll(string(gotBytes), "\r\n", "\n")
+	want := strings.ReplaceAll(string(wantBytes), "\r\n", "\n")
+	if err := AssertNoDiff(got, want, "\n"); err != nil {
+		t.Error(err)
+	}
+}
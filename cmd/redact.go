@@ -0,0 +1,272 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const redactedText = "[REDACTED]"
+
+var defaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token", "id_token",
+	"api_key", "apikey", "client_secret", "private_key",
+}
+
+var defaultRedactHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key", "X-Auth-Token",
+}
+
+var defaultRedactPatterns = []string{
+	`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`, // JWT
+	`\b(?:sk|pk|rk)_(?:live|test)_[0-9A-Za-z]{10,}\b`,   // Stripe
+	`\bAKIA[0-9A-Z]{16}\b`,                              // AWS access key ID
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,                    // GitHub token
+	`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`,                  // Slack token
+}
+
+var emailPattern = regexp.MustCompile(`\b([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`)
+
+// redactRules are what to replace with "[REDACTED]" in sample requests and responses.
+type redactRules struct {
+	// keys are the lowercase names of JSON keys, query parameters, and form fields whose
+	// values are redacted.
+	keys map[string]bool
+
+	// keyPattern matches a JSON key in keys and the key's string or number value.
+	keyPattern *regexp.Regexp
+
+	// queryPattern matches a URL query parameter in keys and the parameter's value.
+	queryPattern *regexp.Regexp
+
+	// headers are the lowercase names of headers whose values are redacted.
+	headers map[string]bool
+
+	// patterns match text to redact anywhere in bodies, headers, and URLs.
+	patterns []*regexp.Regexp
+
+	// emails is whether to mask email addresses, such as "a***@mail.com".
+	emails bool
+}
+
+// newRedactRules combines the default redaction rules with the given rules. The given
+// patterns are regular expressions.
+func newRedactRules(keys, headers, patterns []string, emails bool) (*redactRules, error) {
+	rules := redactRules{
+		keys:    make(map[string]bool),
+		headers: make(map[string]bool),
+		emails:  emails,
+	}
+	quotedKeys := make([]string, 0, len(defaultRedactKeys)+len(keys))
+	for _, key := range append(defaultRedactKeys, keys...) {
+		if !rules.keys[strings.ToLower(key)] {
+			rules.keys[strings.ToLower(key)] = true
+			quotedKeys = append(quotedKeys, regexp.QuoteMeta(key))
+		}
+	}
+	rules.keyPattern = regexp.MustCompile(
+		`(?i)("(?:` + strings.Join(quotedKeys, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"|-?\d[\d.eE+-]*)`,
+	)
+	rules.queryPattern = regexp.MustCompile(`(?i)([?&](?:` + strings.Join(quotedKeys, "|") + `)=)([^&#\s]*)`)
+	for _, header := range append(defaultRedactHeaders, headers...) {
+		rules.headers[strings.ToLower(header)] = true
+	}
+	for _, pattern := range append(defaultRedactPatterns, patterns...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %s", pattern, err)
+		}
+		rules.patterns = append(rules.patterns, re)
+	}
+
+	return &rules, nil
+}
+
+// redactCollection replaces secrets and personal information in each endpoint's
+// request, in each sample response, and in each sample response's original request.
+// It returns a description of each redaction, such as
+// `log in > request body: key "password"`.
+func redactCollection(collection map[string]any, rules *redactRules) []string {
+	report := make([]string, 0)
+	if items, ok := collection["item"].([]any); ok {
+		report = _redactCollection(items, "", rules, report)
+	}
+	return report
+}
+
+func _redactCollection(items []any, namePrefix string, rules *redactRules, report []string) []string {
+	for _, itemAny := range items {
+		item := itemAny.(map[string]any)
+		name := namePrefix + fmt.Sprint(item["name"])
+		if subItemsAny, ok := item["item"]; ok { // if item is a folder
+			report = _redactCollection(subItemsAny.([]any), name+" / ", rules, report)
+			continue
+		}
+		// if item is an endpoint
+		if request, ok := item["request"].(map[string]any); ok {
+			report = rules.redactRequest(request, name+" > request", report)
+		}
+		responses, _ := item["response"].([]any)
+		for i, responseAny := range responses {
+			response := responseAny.(map[string]any)
+			location := fmt.Sprintf("%s > response %d", name, i+1)
+			if originalRequest, ok := response["originalRequest"].(map[string]any); ok {
+				report = rules.redactRequest(originalRequest, location+" original request", report)
+			}
+			report = rules.redactHeaders(response["header"], location+" header", report)
+			if cookies, ok := response["cookie"].([]any); ok {
+				for _, cookieAny := range cookies {
+					if cookie, ok := cookieAny.(map[string]any); ok && cookie["value"] != nil && cookie["value"] != redactedText {
+						cookie["value"] = redactedText
+						report = append(report, fmt.Sprintf("%s cookie: %q", location, fmt.Sprint(cookie["name"])))
+					}
+				}
+			}
+			if body, ok := response["body"].(string); ok {
+				response["body"], report = rules.redactText(body, location+" body", report)
+			}
+		}
+	}
+	return report
+}
+
+// redactRequest redacts a request's headers, URL, body, and auth.
+func (r *redactRules) redactRequest(request map[string]any, location string, report []string) []string {
+	report = r.redactHeaders(request["header"], location+" header", report)
+
+	switch url := request["url"].(type) {
+	case string:
+		request["url"], report = r.redactText(url, location+" URL", report)
+	case map[string]any:
+		if raw, ok := url["raw"].(string); ok {
+			url["raw"], report = r.redactText(raw, location+" URL", report)
+		}
+		report = r.redactKeyValues(url["query"], location+" query", report)
+	}
+
+	if body, ok := request["body"].(map[string]any); ok {
+		if raw, ok := body["raw"].(string); ok {
+			body["raw"], report = r.redactText(raw, location+" body", report)
+		}
+		report = r.redactKeyValues(body["urlencoded"], location+" body", report)
+		report = r.redactKeyValues(body["formdata"], location+" body", report)
+	}
+
+	if auth, ok := request["auth"].(map[string]any); ok {
+		authType, _ := auth["type"].(string)
+		if params, ok := auth[authType].([]any); ok {
+			for _, paramAny := range params {
+				if param, ok := paramAny.(map[string]any); ok && param["value"] != nil && param["value"] != redactedText {
+					param["value"] = redactedText
+					report = append(report, fmt.Sprintf("%s auth: %q", location, fmt.Sprint(param["key"])))
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+// redactHeaders redacts the values of headers in a list of header objects.
+func (r *redactRules) redactHeaders(headersAny any, location string, report []string) []string {
+	headers, _ := headersAny.([]any)
+	for _, headerAny := range headers {
+		header, ok := headerAny.(map[string]any)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprint(header["key"])
+		value, ok := header["value"].(string)
+		if !ok {
+			continue
+		}
+		if r.headers[strings.ToLower(key)] {
+			if value != redactedText {
+				header["value"] = redactedText
+				report = append(report, fmt.Sprintf("%s: %q", location, key))
+			}
+		} else {
+			header["value"], report = r.redactText(value, fmt.Sprintf("%s %q", location, key), report)
+		}
+	}
+	return report
+}
+
+// redactKeyValues redacts the values of query parameters or form fields in a list of
+// objects with "key" and "value" properties.
+func (r *redactRules) redactKeyValues(paramsAny any, location string, report []string) []string {
+	params, _ := paramsAny.([]any)
+	for _, paramAny := range params {
+		param, ok := paramAny.(map[string]any)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprint(param["key"])
+		value, ok := param["value"].(string)
+		if !ok {
+			continue
+		}
+		if r.keys[strings.ToLower(key)] {
+			if value != redactedText {
+				param["value"] = redactedText
+				report = append(report, fmt.Sprintf("%s: key %q", location, key))
+			}
+		} else {
+			param["value"], report = r.redactText(value, fmt.Sprintf("%s %q", location, key), report)
+		}
+	}
+	return report
+}
+
+// redactText redacts the values of JSON keys, URL query parameters, text matching
+// the patterns, and, if chosen, email addresses. The text's formatting is otherwise
+// unchanged.
+func (r *redactRules) redactText(text, location string, report []string) (string, []string) {
+	text = r.keyPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := r.keyPattern.FindStringSubmatch(match)
+		if groups[2] == `"`+redactedText+`"` {
+			return match
+		}
+		report = append(report, fmt.Sprintf("%s: key %s", location, strings.TrimSpace(strings.TrimRight(groups[1], ": \t\r\n"))))
+		return groups[1] + `"` + redactedText + `"`
+	})
+	text = r.queryPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := r.queryPattern.FindStringSubmatch(match)
+		if groups[2] == redactedText {
+			return match
+		}
+		report = append(report, fmt.Sprintf("%s: key %q", location, strings.TrimRight(groups[1][1:], "=")))
+		return groups[1] + redactedText
+	})
+	for _, pattern := range r.patterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			report = append(report, fmt.Sprintf("%s: pattern %q", location, pattern.String()))
+			return redactedText
+		})
+	}
+	if r.emails {
+		text = emailPattern.ReplaceAllStringFunc(text, func(match string) string {
+			groups := emailPattern.FindStringSubmatch(match)
+			masked := groups[1] + "***@" + groups[2]
+			if masked != match {
+				report = append(report, fmt.Sprintf("%s: email", location))
+			}
+			return masked
+		})
+	}
+	return text, report
+}
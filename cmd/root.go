@@ -18,6 +18,7 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -39,13 +40,40 @@ const version = "v0.0.11 (you can check for updates here: https://github.com/whe
 const example = `  pm2md collection.json
   pm2md collection.json output.md
   pm2md collection.json --template=custom.tmpl
-  pm2md test collection.json custom.tmpl expected.md`
+  pm2md collection.json --template=@table
+  pm2md test collection.json custom.tmpl expected.md
+  pm2md build
+  pm2md build api-v2`
 
 var Statuses string
-var CustomTmplPath string
+var CustomTmplPath []string
+var TemplateSearchPath []string
 var GetDefault bool
 var GetMinimal bool
 var ConfirmReplaceExistingFile bool
+var EnvFilePath string
+var Format string
+var Strict bool
+var AnchorStyle string
+var TOCDepth int
+var TOCNumbers bool
+var TOCMethods bool
+var HeadingBase int
+var HeadingOverflow string
+var Redact bool
+var RedactEmails bool
+var Include FilterRules
+var Exclude FilterRules
+var Examples ExamplesConfig
+var Sort SortConfig
+var Inject string
+var Marker string
+var Check bool
+var Collections []string
+var CollectionID string
+var EnvironmentID string
+var APIKeyEnv string
+var APIURL string
 
 var rootCmd = &cobra.Command{
 	Use:     "pm2md [postman_export.json [output.md]]",
@@ -62,39 +90,46 @@ func argsFunc(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 && (GetDefault || GetMinimal) {
 		return nil
 	}
+	if len(CollectionID) > 0 {
+		if len(args) > 1 {
+			return fmt.Errorf("with --collection-id, the only argument is the output file")
+		}
+		return flagTarget().validate()
+	}
 	if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
 		return err
 	}
 	if err := cobra.MaximumNArgs(2)(cmd, args); err != nil {
 		return err
 	}
-	if args[0] != "-" && !strings.HasSuffix(strings.ToLower(args[0]), ".json") {
-		return fmt.Errorf("%q must be \"-\" or end with \".json\"", args[0])
+	if args[0] != "-" && !isCollectionPath(args[0]) {
+		return fmt.Errorf("%q must be \"-\" or end with \".json\", \".json.gz\", or \".zip\"", args[0])
 	}
-	if len(CustomTmplPath) > 0 && !strings.HasSuffix(CustomTmplPath, ".tmpl") {
-		return fmt.Errorf("%q must end with \".tmpl\"", CustomTmplPath)
-	}
-	return nil
+	return flagTarget().validate()
 }
 
 // runFunc parses command args and flags, generates plaintext, and saves the result to a
 // file or prints to stdout.
 func runFunc(cmd *cobra.Command, args []string) error {
-	destPath, destFile, collection, statusRanges, err := parseInput(cmd, args)
-	if err != nil {
+	if len(Inject) > 0 {
+		inputPath, destPath := inputAndDestPaths(args)
+		if len(destPath) > 0 {
+			return fmt.Errorf("choose either an output file or a file to inject into, not both")
+		}
+		cmd.SilenceUsage = true
+		_, err := generateTarget(flagTarget().withOverrides(Target{Input: inputPath}))
 		return err
 	}
-	if destFile != os.Stdout {
-		defer destFile.Close()
+	destPath, destFile, collection, opts, err := parseInput(cmd, args)
+	if err != nil {
+		return err
 	}
 
-	err = generateText(
-		collection,
-		destFile,
-		CustomTmplPath,
-		statusRanges,
-	)
+	err = renderText(collection, destFile, opts)
 	if err != nil {
+		destFile.abort()
+		fmt.Fprintln(os.Stderr, err)
+	} else if err := destFile.commit(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 	} else if destPath != "-" {
 		fmt.Fprintf(os.Stderr, "Created %q\n", destPath)
@@ -104,7 +139,7 @@ func runFunc(cmd *cobra.Command, args []string) error {
 
 // parseInput parses command args and flags, opens the destination file, and returns all
 // of these results.
-func parseInput(cmd *cobra.Command, args []string) (string, *os.File, map[string]any, [][]int, error) {
+func parseInput(cmd *cobra.Command, args []string) (string, *atomicFile, map[string]any, renderOptions, error) {
 	if GetDefault {
 		fileName := exportText("default", ".tmpl", defaultTmplStr)
 		fmt.Fprintf(os.Stderr, "Created %q\n", fileName)
@@ -120,38 +155,41 @@ func parseInput(cmd *cobra.Command, args []string) (string, *os.File, map[string
 		}
 	}
 
-	jsonPath := args[0]
-	var destPath string
-	if len(args) == 2 {
-		destPath = args[1]
-	}
+	jsonPath, destPath := inputAndDestPaths(args)
 
-	statusRanges, err := parseStatusRanges(Statuses)
+	opts, err := flagTarget().renderOptions()
 	if err != nil {
-		return "", nil, nil, nil, err
+		return "", nil, nil, renderOptions{}, err
 	}
 
-	var jsonBytes []byte
-	if jsonPath == "-" {
-		jsonBytes, err = ScanStdin()
-	} else {
-		jsonBytes, err = os.ReadFile(jsonPath)
-	}
+	collection, err := loadCollection(flagTarget().withOverrides(Target{Input: jsonPath}))
 	if err != nil {
-		return "", nil, nil, nil, err
-	}
-	collection, err := parseCollection(jsonBytes)
-	if err != nil {
-		return "", nil, nil, nil, err
+		return "", nil, nil, renderOptions{}, err
 	}
 
 	collectionName := collection["info"].(map[string]any)["name"].(string)
 	destFile, destPath, err := openDestFile(destPath, collectionName, ConfirmReplaceExistingFile)
 	if err != nil {
-		return "", nil, nil, nil, err
+		return "", nil, nil, renderOptions{}, err
 	}
 
-	return destPath, destFile, collection, statusRanges, nil
+	return destPath, destFile, collection, opts, nil
+}
+
+// inputAndDestPaths returns the input path and the destination path from the command's
+// args. Either may be empty. With --collection-id, there is no input path, so the only
+// arg is the destination path.
+func inputAndDestPaths(args []string) (string, string) {
+	if len(CollectionID) > 0 {
+		if len(args) > 0 {
+			return "", args[0]
+		}
+		return "", ""
+	}
+	if len(args) == 2 {
+		return args[0], args[1]
+	}
+	return args[0], ""
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -165,20 +203,38 @@ func Execute() {
 
 func init() {
 	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(buildCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(coverageCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(collectionsCmd)
+	rootCmd.AddCommand(fieldsCmd)
+	rootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesListCmd)
+	templatesCmd.AddCommand(templatesGetCmd)
+	templatesCmd.AddCommand(templatesLintCmd)
 
-	rootCmd.Flags().StringVarP(
+	rootCmd.PersistentFlags().StringVarP(
 		&Statuses,
 		"statuses",
 		"s",
 		"",
-		"Include only the sample responses with status codes in given range(s)",
+		"Include only the sample responses with status codes in given range(s), such as \"2xx,!204,500-\"",
 	)
-	rootCmd.Flags().StringVarP(
+	rootCmd.PersistentFlags().StringArrayVarP(
 		&CustomTmplPath,
 		"template",
 		"t",
-		"",
-		"Use a custom template for the output",
+		nil,
+		"Use custom template file(s) or folder(s), or a built-in template such as @table (see: pm2md templates list)",
+	)
+	rootCmd.PersistentFlags().StringArrayVar(
+		&TemplateSearchPath,
+		"template-path",
+		nil,
+		"Folder(s) of partial templates with define blocks that templates can use",
 	)
 	rootCmd.Flags().BoolVarP(
 		&GetDefault,
@@ -194,37 +250,375 @@ func init() {
 		false,
 		"Creates a file of a minimal template for customization",
 	)
-	rootCmd.Flags().BoolVar(
+	rootCmd.PersistentFlags().StringVarP(
+		&EnvFilePath,
+		"env",
+		"e",
+		"",
+		"Replace {{variables}} with the values in a Postman environment file",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Format,
+		"format",
+		"",
+		"The output format: markdown or html, which is for HTML templates and escapes values with html/template (default \"markdown\")",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&Strict,
+		"strict",
+		false,
+		"Fail if the template uses a field that the data doesn't have",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&AnchorStyle,
+		"anchors",
+		"",
+		fmt.Sprintf(
+			"The style of links to headers: %s (default %q)",
+			strings.Join(anchorStyleNames(), ", "), defaultAnchorStyle,
+		),
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&TOCDepth,
+		"toc-depth",
+		0,
+		"The deepest level of items in the table of contents (default no limit)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&TOCNumbers,
+		"toc-numbers",
+		false,
+		"Number the entries in the table of contents",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&TOCMethods,
+		"toc-methods",
+		false,
+		"Show each endpoint's method and path in the table of contents",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&HeadingBase,
+		"heading-base",
+		0,
+		"The header level of the collection's name and outermost items (default 1)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&HeadingOverflow,
+		"heading-overflow",
+		"",
+		"What to do with headers deeper than h6: clamp or bold (default \"clamp\")",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&Redact,
+		"redact",
+		false,
+		"Replace passwords, tokens, and other secrets in sample requests and responses",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&RedactEmails,
+		"redact-emails",
+		false,
+		"Mask email addresses in sample requests and responses (implies --redact)",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&Include.Folders,
+		"include-folder",
+		nil,
+		"Include only the endpoints in folders with paths matching the given glob(s), such as \"public/*\"",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&Exclude.Folders,
+		"exclude-folder",
+		nil,
+		"Exclude the endpoints in folders with paths matching the given glob(s)",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&Include.Methods,
+		"include-method",
+		nil,
+		"Include only the endpoints with the given HTTP method(s)",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&Exclude.Methods,
+		"exclude-method",
+		nil,
+		"Exclude the endpoints with the given HTTP method(s)",
+	)
+	rootCmd.PersistentFlags().StringArrayVar(
+		&Include.Names,
+		"include-name",
+		nil,
+		"Include only the endpoints with names matching the given regular expression(s)",
+	)
+	rootCmd.PersistentFlags().StringArrayVar(
+		&Exclude.Names,
+		"exclude-name",
+		nil,
+		"Exclude the endpoints with names matching the given regular expression(s)",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&Include.Tags,
+		"include-tag",
+		nil,
+		"Include only the endpoints with the given tag(s), such as @public, in their or their folders' descriptions",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&Exclude.Tags,
+		"exclude-tag",
+		nil,
+		"Exclude the endpoints with the given tag(s), such as @internal, in their or their folders' descriptions",
+	)
+	rootCmd.PersistentFlags().StringArrayVar(
+		&Examples.Names,
+		"example-name",
+		nil,
+		"Include only the sample responses with names matching the given regular expression(s)",
+	)
+	rootCmd.PersistentFlags().StringArrayVar(
+		&Examples.ExcludeNames,
+		"exclude-example-name",
+		nil,
+		"Exclude the sample responses with names matching the given regular expression(s)",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&Examples.ContentTypes,
+		"example-type",
+		nil,
+		"Include only the sample responses with the given content type(s), such as json or text/html",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&Examples.Headers,
+		"example-header",
+		nil,
+		"Include only the sample responses that have all the given header(s)",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&Examples.Max,
+		"max-examples",
+		0,
+		"The most sample responses to include for each endpoint (default no limit)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Sort.Items,
+		"sort",
+		"",
+		fmt.Sprintf("The order of the endpoints in each folder: %s (default postman)", strings.Join(itemOrders, ", ")),
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Sort.Folders,
+		"sort-folders",
+		"",
+		fmt.Sprintf("The order of the subfolders in each folder: %s (default postman)", strings.Join(folderOrders, ", ")),
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Sort.Examples,
+		"sort-examples",
+		"",
+		fmt.Sprintf("The order of each endpoint's sample responses: %s (default postman)", strings.Join(exampleOrders, ", ")),
+	)
+	rootCmd.PersistentFlags().StringArrayVar(
+		&Collections,
+		"collection",
+		nil,
+		"Choose a collection by name or ID from an input with many, such as a Postman data export",
+	)
+	rootCmd.Flags().StringVar(
+		&CollectionID,
+		"collection-id",
+		"",
+		"Fetch the collection with this UID from the Postman API instead of reading a file",
+	)
+	rootCmd.Flags().StringVar(
+		&EnvironmentID,
+		"environment-id",
+		"",
+		"Fetch the environment with this UID from the Postman API and replace {{variables}} with its values",
+	)
+	rootCmd.Flags().StringVar(
+		&APIKeyEnv,
+		"api-key-env",
+		"",
+		"The environment variable with your Postman API key (default POSTMAN_API_KEY)",
+	)
+	rootCmd.Flags().StringVar(
+		&APIURL,
+		"api-url",
+		"",
+		"The base URL of the Postman API (default https://api.getpostman.com)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Inject,
+		"inject",
+		"",
+		"Replace only the part of an existing file between <!-- pm2md:start --> and <!-- pm2md:end --> markers",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Marker,
+		"marker",
+		"",
+		"With --inject, use the markers with this name, such as <!-- pm2md:start users -->",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&Check,
+		"check",
+		false,
+		"With --inject, don't change the file but fail if it's out of date",
+	)
+	rootCmd.PersistentFlags().BoolVar(
 		&ConfirmReplaceExistingFile,
 		"replace",
 		false,
 		"Confirm whether to replace a chosen existing output file",
 	)
-	rootCmd.Flags().MarkHidden("replace")
+	rootCmd.PersistentFlags().MarkHidden("replace")
+
+	batchCmd.Flags().StringVarP(
+		&OutputPattern,
+		"output",
+		"o",
+		defaultOutputPattern,
+		"The pattern for each output path. The placeholders are {name}, {dir}, and {collection}",
+	)
+	batchCmd.Flags().IntVarP(
+		&Jobs,
+		"jobs",
+		"j",
+		runtime.NumCPU(),
+		"The number of collections to convert at the same time",
+	)
+	batchCmd.Flags().BoolVar(
+		&KeepExisting,
+		"keep-existing",
+		false,
+		"Fail instead of replacing output files that already exist",
+	)
+
+	lintCmd.Flags().StringVar(
+		&LintReport,
+		"report",
+		"text",
+		fmt.Sprintf("The report format: %s", strings.Join(lintReportFormats, ", ")),
+	)
+	lintCmd.Flags().StringVar(
+		&LintFailOn,
+		"fail-on",
+		"error",
+		fmt.Sprintf("Fail if any problem is at or above this severity: none, %s", strings.Join(severities, ", ")),
+	)
+	lintCmd.Flags().StringToStringVar(
+		&LintRules,
+		"rule",
+		nil,
+		"Change a rule's severity, such as no-examples=error, or turn it off, such as missing-description=off",
+	)
+
+	collectionsCmd.Flags().StringVar(
+		&CollectionsReport,
+		"report",
+		"text",
+		fmt.Sprintf("The report format: %s", strings.Join(collectionsReportFormats, ", ")),
+	)
+	fieldsCmd.Flags().StringVar(
+		&FieldsReport,
+		"report",
+		"text",
+		fmt.Sprintf("The report format: %s", strings.Join(fieldsReportFormats, ", ")),
+	)
+	coverageCmd.Flags().StringVar(
+		&CoverageReport,
+		"report",
+		"markdown",
+		fmt.Sprintf("The report format: %s", strings.Join(coverageReportFormats, ", ")),
+	)
+	coverageCmd.Flags().StringVar(
+		&CoverageBadgePath,
+		"badge",
+		"",
+		"Save an SVG badge showing the coverage score to the given path, replacing any existing file",
+	)
+
+	mergeCmd.Flags().StringVar(
+		&MergeTitle,
+		"title",
+		"",
+		"The title of the merged document (default the collections' names)",
+	)
+	mergeCmd.Flags().StringVarP(
+		&MergeOutput,
+		"output",
+		"o",
+		"",
+		"The output file, or \"-\" for stdout (default a new file named after the title)",
+	)
+	mergeCmd.Flags().StringToStringVar(
+		&MergePrefixes,
+		"prefix",
+		nil,
+		"Add a prefix to a collection's variables, such as users.json=users_",
+	)
 }
 
-// openDestFile gets the destination file and its path. If the given destination path is
-// "-", the destination file is os.Stdout. If the given destination path is empty, a new
-// file is created with a path based on the collection name and the returned path will
-// be different from the given one. If the given destination path refers to an existing
-// file and confirmation to replace an existing file is not given, an error is returned.
-// Any returned file is open.
-func openDestFile(destPath, collectionName string, confirmReplaceExistingFile bool) (*os.File, string, error) {
-	if destPath == "-" {
-		return os.Stdout, destPath, nil
+// flagTarget returns a target made from the values of the command's flags. Any flags
+// that weren't used have empty values.
+func flagTarget() Target {
+	return Target{
+		Collections:   Collections,
+		CollectionID:  CollectionID,
+		EnvironmentID: EnvironmentID,
+		APIKeyEnv:     APIKeyEnv,
+		APIURL:        APIURL,
+		Template:      CustomTmplPath,
+		TemplatePath:  TemplateSearchPath,
+		Statuses:      Statuses,
+		EnvFile:       EnvFilePath,
+		Format:        Format,
+		Strict:        Strict,
+		Anchors:       AnchorStyle,
+		TOC: TOCConfig{
+			Depth:   TOCDepth,
+			Numbers: TOCNumbers,
+			Methods: TOCMethods,
+		},
+		Headings: HeadingsConfig{
+			Base:     HeadingBase,
+			Overflow: HeadingOverflow,
+		},
+		Redact: RedactConfig{
+			Enabled: Redact,
+			Emails:  RedactEmails,
+		},
+		Filter: FilterConfig{
+			Include: Include,
+			Exclude: Exclude,
+		},
+		Examples: Examples,
+		Sort:     Sort,
+		Inject:   Inject,
+		Marker:   Marker,
+		Check:    Check,
+		Replace:  ConfirmReplaceExistingFile,
 	}
+}
+
+// openDestFile gets the destination file and its path. If the given destination path is
+// "-", the destination is stdout. If the given destination path is empty, the path is
+// based on the collection name and will be different from the given one. If the given
+// destination path refers to an existing file and confirmation to replace an existing
+// file is not given, an error is returned. Nothing is saved or printed until the returned
+// file is committed, so a failed conversion doesn't leave a partial file behind.
+func openDestFile(destPath, collectionName string, confirmReplaceExistingFile bool) (*atomicFile, string, error) {
 	if len(destPath) == 0 {
 		fileName := FormatFileName(collectionName)
 		if len(fileName) == 0 {
 			fileName = "collection"
 		}
 		destPath = CreateUniqueFileName(fileName, ".md")
-	} else if FileExists(destPath) && !confirmReplaceExistingFile {
+	} else if destPath != "-" && FileExists(destPath) && !confirmReplaceExistingFile {
 		return nil, "", fmt.Errorf("file %q already exists. Run the command again with the --replace flag to confirm replacing it", destPath)
 	}
-	destFile, err := os.Create(destPath)
+	destFile, err := createAtomicFile(destPath)
 	if err != nil {
-		return nil, "", fmt.Errorf("os.Create: %s", err)
+		return nil, "", err
 	}
 	return destFile, destPath, nil
 }
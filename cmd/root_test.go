@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -69,42 +70,40 @@ func TestArgsFuncGetTemplate(t *testing.T) {
 }
 
 func TestArgsFuncWithCustomTmplPath(t *testing.T) {
-	CustomTmplPath = "custom.tmpl"
+	CustomTmplPath = []string{"custom.tmpl"}
 	err := argsFunc(nil, []string{"api.json"})
 	if err != nil {
 		t.Errorf("argsFunc(nil, []string{\"api.json\"}) = %q, want nil", err)
 	}
-	CustomTmplPath = ""
+	CustomTmplPath = nil
 }
 
 func TestArgsFuncWithInvalidCustomTmplPath(t *testing.T) {
-	CustomTmplPath = "custom.template"
+	CustomTmplPath = []string{"custom.template"}
 	err := argsFunc(nil, []string{"api.json"})
 	if err == nil {
 		t.Errorf("argsFunc(nil, []string{\"api.json\"}) = nil, want non-nil error")
 	}
-	CustomTmplPath = ""
+	CustomTmplPath = nil
 }
 
 func TestParseInputWithInvalidStatuses(t *testing.T) {
 	jsonPath := "../samples/calendar-API.postman_collection.json"
 	Statuses = "this is not a valid statuses value"
-	destPath, destFile, _, _, err := parseInput(nil, []string{jsonPath})
+	_, destFile, _, _, err := parseInput(nil, []string{jsonPath})
 	Statuses = ""
 	if err == nil {
 		t.Error("parseInput(nil, []string{\"\"}) with invalid statuses returned nil error, want non-nil error")
-		defer os.Remove(destPath)
-		defer destFile.Close()
+		destFile.abort()
 	}
 }
 
 func TestParseInputWithInvalidJsonPath(t *testing.T) {
 	jsonPath := "nonexistent.json"
-	destPath, destFile, _, _, err := parseInput(nil, []string{jsonPath})
+	_, destFile, _, _, err := parseInput(nil, []string{jsonPath})
 	if err == nil {
 		t.Errorf("parseInput(nil, []string{%q}) returned nil error, want non-nil error", jsonPath)
-		defer os.Remove(destPath)
-		defer destFile.Close()
+		destFile.abort()
 	}
 }
 
@@ -115,11 +114,10 @@ func TestParseInputExistingFileError(t *testing.T) {
 		t.Errorf("Test broken. Expected file %q to exist", destPath)
 		return
 	}
-	destPath, destFile, _, _, err := parseInput(nil, []string{jsonPath, destPath})
+	_, destFile, _, _, err := parseInput(nil, []string{jsonPath, destPath})
 	if err == nil {
 		t.Errorf("parseInput(nil, []string{%q, %q}) returned nil error, want non-nil error", jsonPath, destPath)
-		defer os.Remove(destPath)
-		defer destFile.Close()
+		destFile.abort()
 	}
 }
 
@@ -168,3 +166,29 @@ func TestLoadTmplNonexistent(t *testing.T) {
 		t.Errorf("loadTmpl(\"nonexistent.tmpl\") = (%q, len %d template, nil), want non-nil error", tmplName, len(tmplStr))
 	}
 }
+
+func TestTemplateFlagsKeepCommas(t *testing.T) {
+	tests := []struct {
+		flagName string
+		value    *[]string
+	}{
+		{"template", &CustomTmplPath},
+		{"template-path", &TemplateSearchPath},
+	}
+
+	for _, test := range tests {
+		t.Run(test.flagName, func(t *testing.T) {
+			defer func() { *test.value = nil }()
+			flag := rootCmd.PersistentFlags().Lookup(test.flagName)
+			for _, path := range []string{"docs,v2/api.tmpl", "other.tmpl"} {
+				if err := flag.Value.Set(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := []string{"docs,v2/api.tmpl", "other.tmpl"}
+			if !reflect.DeepEqual(*test.value, want) {
+				t.Errorf("--%s values = %q, want %q", test.flagName, *test.value, want)
+			}
+		})
+	}
+}
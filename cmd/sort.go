@@ -0,0 +1,158 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var itemOrders = []string{"postman", "name", "path"}
+var folderOrders = []string{"postman", "name"}
+var exampleOrders = []string{"postman", "status"}
+
+// methodOrder is the order of endpoints with the same path when sorting by path. Other
+// methods come after these in alphabetical order.
+var methodOrder = []string{"GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH", "DELETE"}
+
+// sortOptions are the orders of endpoints, folders, and sample responses. Each empty
+// order is "postman", which keeps the order from the collection.
+type sortOptions struct {
+	// items is the order of the endpoints in each folder: "postman", "name", or "path",
+	// which sorts by URL path and then by method.
+	items string
+
+	// folders is the order of the subfolders in each folder: "postman" or "name".
+	folders string
+
+	// examples is the order of each endpoint's sample responses: "postman" or "status".
+	examples string
+}
+
+// parseSortOptions validates the orders of endpoints, folders, and sample responses and
+// combines them.
+func parseSortOptions(config SortConfig) (sortOptions, error) {
+	orders := []struct {
+		kind, order string
+		choices     []string
+	}{
+		{"item", config.Items, itemOrders},
+		{"folder", config.Folders, folderOrders},
+		{"example", config.Examples, exampleOrders},
+	}
+	for _, o := range orders {
+		if len(o.order) > 0 && !slices.Contains(o.choices, strings.ToLower(o.order)) {
+			return sortOptions{}, fmt.Errorf(
+				"unknown %s order %q. The %s orders are: %s",
+				o.kind, o.order, o.kind, strings.Join(o.choices, ", "),
+			)
+		}
+	}
+	return sortOptions{
+		items:    strings.ToLower(config.Items),
+		folders:  strings.ToLower(config.Folders),
+		examples: strings.ToLower(config.Examples),
+	}, nil
+}
+
+// sortCollection sorts the endpoints, folders, and sample responses in the collection.
+// Endpoints and folders are sorted separately, so each folder's endpoints and subfolders
+// stay in the same positions relative to each other. Sorts are stable, so items that are
+// equal by an order keep their order from the collection.
+func sortCollection(collection map[string]any, opts sortOptions) {
+	items := collection["item"].([]any)
+	_sortCollection(items, opts)
+}
+
+func _sortCollection(items []any, opts sortOptions) {
+	var folderIndexes, endpointIndexes []int
+	for i, itemAny := range items {
+		item := itemAny.(map[string]any)
+		if subItemsAny, ok := item["item"]; ok { // if item is a folder
+			folderIndexes = append(folderIndexes, i)
+			_sortCollection(subItemsAny.([]any), opts)
+		} else { // if item is an endpoint
+			endpointIndexes = append(endpointIndexes, i)
+			if opts.examples == "status" {
+				responses, _ := item["response"].([]any)
+				slices.SortStableFunc(responses, compareExampleStatuses)
+			}
+		}
+	}
+
+	switch opts.items {
+	case "name":
+		sortAt(items, endpointIndexes, compareItemNames)
+	case "path":
+		sortAt(items, endpointIndexes, compareEndpointPaths)
+	}
+	if opts.folders == "name" {
+		sortAt(items, folderIndexes, compareItemNames)
+	}
+}
+
+// sortAt stably sorts the elements at the given increasing indexes of a slice among
+// themselves. Other elements are not moved.
+func sortAt(items []any, indexes []int, compare func(a, b any) int) {
+	selected := make([]any, len(indexes))
+	for i, index := range indexes {
+		selected[i] = items[index]
+	}
+	slices.SortStableFunc(selected, compare)
+	for i, index := range indexes {
+		items[index] = selected[i]
+	}
+}
+
+// compareItemNames compares two items' names without regard to case.
+func compareItemNames(a, b any) int {
+	aName := fmt.Sprint(a.(map[string]any)["name"])
+	bName := fmt.Sprint(b.(map[string]any)["name"])
+	return cmp.Compare(strings.ToLower(aName), strings.ToLower(bName))
+}
+
+// compareEndpointPaths compares two endpoints' URL paths, and then their methods.
+func compareEndpointPaths(a, b any) int {
+	aMethod, aPath := methodAndPath(a.(map[string]any))
+	bMethod, bPath := methodAndPath(b.(map[string]any))
+	if c := cmp.Compare(aPath, bPath); c != 0 {
+		return c
+	}
+	return compareMethods(strings.ToUpper(aMethod), strings.ToUpper(bMethod))
+}
+
+// compareMethods compares two uppercase HTTP methods by methodOrder, and then
+// alphabetically.
+func compareMethods(a, b string) int {
+	rank := func(method string) int {
+		if i := slices.Index(methodOrder, method); i >= 0 {
+			return i
+		}
+		return len(methodOrder)
+	}
+	if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+		return c
+	}
+	return cmp.Compare(a, b)
+}
+
+// compareExampleStatuses compares two sample responses' status codes.
+func compareExampleStatuses(a, b any) int {
+	aCode, _ := a.(map[string]any)["code"].(float64)
+	bCode, _ := b.(map[string]any)["code"].(float64)
+	return cmp.Compare(aCode, bCode)
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newSortTestCollection creates a collection with folders, endpoints, and sample
+// responses in no particular order for testing sorting.
+func newSortTestCollection() map[string]any {
+	endpoint := func(name, method, path string, codes ...float64) map[string]any {
+		responses := make([]any, len(codes))
+		for i, code := range codes {
+			responses[i] = map[string]any{"name": name, "code": code}
+		}
+		return map[string]any{
+			"name":     name,
+			"request":  map[string]any{"method": method, "url": map[string]any{"path": []any{path}}},
+			"response": responses,
+		}
+	}
+	return map[string]any{
+		"info": map[string]any{"name": "API"},
+		"item": []any{
+			endpoint("update event", "PUT", "events", 404, 200),
+			map[string]any{
+				"name": "users",
+				"item": []any{
+					endpoint("list users", "GET", "users"),
+					endpoint("Create user", "POST", "users", 500, 201, 400),
+				},
+			},
+			endpoint("delete event", "DELETE", "events"),
+			map[string]any{
+				"name": "admin",
+				"item": []any{endpoint("audit", "GET", "audit")},
+			},
+			endpoint("list events", "GET", "events", 200),
+		},
+	}
+}
+
+func TestSortCollection(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SortConfig
+		want   []string
+	}{
+		{
+			"postman",
+			SortConfig{},
+			[]string{"update event", "users/list users", "users/Create user", "delete event", "admin/audit", "list events"},
+		},
+		{
+			"name",
+			SortConfig{Items: "name"},
+			[]string{"delete event", "users/Create user", "users/list users", "list events", "admin/audit", "update event"},
+		},
+		{
+			"path",
+			SortConfig{Items: "path"},
+			[]string{"list events", "users/list users", "users/Create user", "update event", "admin/audit", "delete event"},
+		},
+		{
+			"folders only",
+			SortConfig{Folders: "name"},
+			[]string{"update event", "admin/audit", "delete event", "users/list users", "users/Create user", "list events"},
+		},
+		{
+			"names and folders",
+			SortConfig{Items: "NAME", Folders: "name"},
+			[]string{"delete event", "admin/audit", "list events", "users/Create user", "users/list users", "update event"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := parseSortOptions(test.config)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			collection := newSortTestCollection()
+			sortCollection(collection, opts)
+			ans := endpointPaths(collection["item"].([]any), "")
+			if !reflect.DeepEqual(ans, test.want) {
+				t.Errorf("got endpoints %q, want %q", ans, test.want)
+			}
+		})
+	}
+}
+
+func TestSortExamplesByStatus(t *testing.T) {
+	opts, err := parseSortOptions(SortConfig{Examples: "status"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	collection := newSortTestCollection()
+	sortCollection(collection, opts)
+	users := collection["item"].([]any)[1].(map[string]any)
+	createUser := users["item"].([]any)[1].(map[string]any)
+	ans := make([]float64, 0)
+	for _, responseAny := range createUser["response"].([]any) {
+		ans = append(ans, responseAny.(map[string]any)["code"].(float64))
+	}
+	want := []float64{201, 400, 500}
+	if !reflect.DeepEqual(ans, want) {
+		t.Errorf("got statuses %v, want %v", ans, want)
+	}
+}
+
+func TestParseSortOptionsWithUnknownOrders(t *testing.T) {
+	tests := []SortConfig{
+		{Items: "method"},
+		{Folders: "path"},
+		{Examples: "name"},
+	}
+
+	for _, test := range tests {
+		if _, err := parseSortOptions(test); err == nil {
+			t.Errorf("parseSortOptions(%v) returned nil error, want non-nil error", test)
+		}
+	}
+}
+
+func TestMaxExamplesAfterSortingByStatus(t *testing.T) {
+	sortOpts, err := parseSortOptions(SortConfig{Examples: "status"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	examples, err := newExampleFilter(ExamplesConfig{Max: 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	collection := newSortTestCollection()
+	prepareCollection(collection, anchorStyles[defaultAnchorStyle], renderOptions{sort: sortOpts, examples: examples})
+	users := collection["item"].([]any)[1].(map[string]any)
+	createUser := users["item"].([]any)[1].(map[string]any)
+	ans := make([]float64, 0)
+	for _, responseAny := range createUser["response"].([]any) {
+		ans = append(ans, responseAny.(map[string]any)["code"].(float64))
+	}
+	want := []float64{201}
+	if !reflect.DeepEqual(ans, want) {
+		t.Errorf("got statuses %v, want %v", ans, want)
+	}
+}
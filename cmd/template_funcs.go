@@ -0,0 +1,480 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The functions in this file are the general-purpose template functions. Functions
+// that take the value to work on take it as their last argument so that they can be
+// used at the end of a pipeline, such as `{{.name | replace " " "-"}}`.
+
+// libraryFuncs returns the general-purpose template functions by name.
+func libraryFuncs() map[string]any {
+	return map[string]any{
+		"lower":           strings.ToLower,
+		"upper":           strings.ToUpper,
+		"title":           title,
+		"trim":            strings.TrimSpace,
+		"trimPrefix":      trimPrefix,
+		"trimSuffix":      trimSuffix,
+		"split":           split,
+		"replace":         replace,
+		"regexMatch":      regexMatch,
+		"regexReplace":    regexReplace,
+		"default":         defaultValue,
+		"coalesce":        coalesce,
+		"empty":           isEmpty,
+		"dict":            dict,
+		"list":            list,
+		"sortBy":          sortBy,
+		"groupBy":         groupBy,
+		"toJson":          toJSON,
+		"toPrettyJson":    toPrettyJSON,
+		"fromJson":        fromJSON,
+		"jsonPath":        jsonPath,
+		"now":             time.Now,
+		"formatDate":      formatDate,
+		"escapeMarkdown":  escapeMarkdown,
+		"escapeTableCell": escapeTableCell,
+		"escapeHTML":      escapeHTML,
+		"escapeAttr":      escapeAttr,
+		"codeFence":       codeFence,
+		"codeLang":        codeLang,
+		"indent":          indent,
+		"wrap":            wrap,
+	}
+}
+
+// title uppercases the first letter of each word and leaves the other letters as they
+// are.
+func title(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '-' || runes[i-1] == '_' {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+// trimPrefix removes a prefix from s if s starts with it.
+func trimPrefix(prefix, s string) string {
+	return strings.TrimPrefix(s, prefix)
+}
+
+// trimSuffix removes a suffix from s if s ends with it.
+func trimSuffix(suffix, s string) string {
+	return strings.TrimSuffix(s, suffix)
+}
+
+// split splits s into the parts between each separator.
+func split(sep, s string) []any {
+	parts := strings.Split(s, sep)
+	result := make([]any, len(parts))
+	for i, part := range parts {
+		result[i] = part
+	}
+	return result
+}
+
+// replace replaces each old substring in s with new.
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+// regexMatch reports whether s contains a match of the regular expression.
+func regexMatch(pattern, s string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+// regexReplace replaces each match of the regular expression in s. In the replacement,
+// $1 or ${1} is the text of the first group, and so on.
+func regexReplace(pattern, replacement, s string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(s, replacement), nil
+}
+
+// defaultValue returns the value, or the default if the value is empty.
+func defaultValue(def, value any) any {
+	if isEmpty(value) {
+		return def
+	}
+	return value
+}
+
+// coalesce returns the first value that isn't empty, or nil if they all are.
+func coalesce(values ...any) any {
+	for _, v := range values {
+		if !isEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// isEmpty reports whether a value is missing, false, zero, or an empty string, list, or
+// map.
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// dict creates a map from pairs of keys and values, such as to pass several values to
+// a template: `{{template "row" dict "item" . "level" 2}}`.
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict needs pairs of keys and values, but got %d arguments", len(pairs))
+	}
+	result := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings, but key %v is a %T", pairs[i], pairs[i])
+		}
+		result[key] = pairs[i+1]
+	}
+	return result, nil
+}
+
+// list creates a list of the given values.
+func list(values ...any) []any {
+	return append([]any{}, values...)
+}
+
+// toList converts a slice or array of any type to a list.
+func toList(items any) ([]any, error) {
+	if items == nil {
+		return nil, nil
+	}
+	if list, ok := items.([]any); ok {
+		return list, nil
+	}
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, but got a %T", items)
+	}
+	result := make([]any, v.Len())
+	for i := range result {
+		result[i] = v.Index(i).Interface()
+	}
+	return result, nil
+}
+
+// sortBy returns a copy of the items sorted by the value at the given path in each
+// item, such as "name" or "request.method". Numbers are sorted numerically and other
+// values as text, ignoring case. Items without the value are sorted last, and items
+// with the same value keep their order.
+func sortBy(path string, items any) ([]any, error) {
+	list, err := toList(items)
+	if err != nil {
+		return nil, err
+	}
+	sorted := append([]any{}, list...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := jsonPath(path, sorted[i]), jsonPath(path, sorted[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		aNum, aIsNum := a.(float64)
+		bNum, bIsNum := b.(float64)
+		if aIsNum && bIsNum {
+			return aNum < bNum
+		}
+		return strings.ToLower(fmt.Sprint(a)) < strings.ToLower(fmt.Sprint(b))
+	})
+	return sorted, nil
+}
+
+// groupBy groups the items by the value at the given path in each item. Each group is a
+// map with a "key" and its "items", and the groups are in the order their keys first
+// appear. Items without the value are grouped under an empty key.
+func groupBy(path string, items any) ([]any, error) {
+	list, err := toList(items)
+	if err != nil {
+		return nil, err
+	}
+	var groups []any
+	indexes := make(map[string]int)
+	for _, item := range list {
+		key := ""
+		if value := jsonPath(path, item); value != nil {
+			key = fmt.Sprint(value)
+		}
+		i, ok := indexes[key]
+		if !ok {
+			i = len(groups)
+			indexes[key] = i
+			groups = append(groups, map[string]any{"key": key, "items": []any{}})
+		}
+		group := groups[i].(map[string]any)
+		group["items"] = append(group["items"].([]any), item)
+	}
+	return groups, nil
+}
+
+// toJSON encodes a value as compact JSON.
+func toJSON(value any) (string, error) {
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+// toPrettyJSON encodes a value as JSON indented with four spaces.
+func toPrettyJSON(value any) (string, error) {
+	b, err := json.MarshalIndent(value, "", "    ")
+	return string(b), err
+}
+
+// fromJSON decodes JSON text, such as a request body, so that its fields can be used.
+func fromJSON(s string) (any, error) {
+	var value any
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return nil, fmt.Errorf("fromJson: %s", err)
+	}
+	return value, nil
+}
+
+// jsonPath returns the value at a path of dot-separated keys and list indexes, such as
+// "request.url.path.0", or nil if there is no value there. A leading "$." or "." is
+// ignored, and an empty path returns the value itself.
+func jsonPath(path string, value any) any {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if len(path) == 0 {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			var ok bool
+			if value, ok = v[key]; !ok {
+				return nil
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// dateLayouts is the date formats that formatDate can read.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123,
+	time.RFC1123Z,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+}
+
+// formatDate formats a date with a Go time layout, such as "2006-01-02" or "Jan 2,
+// 2006". The date can be a time, a Unix timestamp in seconds, or text in the RFC 3339 or
+// RFC 1123 format or like "2006-01-02".
+func formatDate(layout string, date any) (string, error) {
+	var t time.Time
+	switch d := date.(type) {
+	case time.Time:
+		t = d
+	case float64:
+		t = time.Unix(int64(d), 0).UTC()
+	case int:
+		t = time.Unix(int64(d), 0).UTC()
+	case int64:
+		t = time.Unix(d, 0).UTC()
+	case string:
+		var err error
+		for _, dateLayout := range dateLayouts {
+			if t, err = time.Parse(dateLayout, d); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("formatDate: unknown date format: %q", d)
+		}
+	default:
+		return "", fmt.Errorf("formatDate: expected a date, but got a %T", date)
+	}
+	return t.Format(layout), nil
+}
+
+// escapeMarkdown puts a backslash before each character that markdown might treat as
+// formatting, so that the text shows as it is.
+func escapeMarkdown(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\`*_{}[]()<>#+-.!|~", r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// escapeTableCell makes text safe to put in a markdown table cell. Pipes are escaped so
+// they don't start a new cell, and line breaks become <br> tags so they don't end the
+// row. Other formatting is kept; use escapeMarkdown first to show the text as it is.
+// Values that aren't text, such as numbers, are converted to text first.
+func escapeTableCell(value any) string {
+	if value == nil {
+		return ""
+	}
+	s := strings.ReplaceAll(strings.TrimSpace(fmt.Sprint(value)), "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if r == '|' && !escaped {
+			b.WriteRune('\\')
+		}
+		escaped = r == '\\' && !escaped
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// escapeHTML escapes the characters that have special meanings in HTML, such as "<", so
+// that text shows as it is in HTML tags written by a markdown template. With the html
+// format, values are already escaped and this isn't needed.
+func escapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+// escapeAttr escapes text to put in a quoted HTML attribute value, such as
+// `<a title="{{escapeAttr .description}}">`. Line breaks are escaped too so that the
+// attribute stays on one line.
+func escapeAttr(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "\r", "&#13;")
+	return strings.ReplaceAll(s, "\n", "&#10;")
+}
+
+// codeFence returns a code fence of backticks that is longer than any run of backticks
+// in the code, so that the code can't end its code block early. The fence is at least
+// three backticks long.
+func codeFence(code string) string {
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// codeLang guesses the language name for a code block from a content type such as
+// "application/json; charset=utf-8", a Postman body language such as "json", or
+// otherwise from the code itself. It returns an empty string if it can't tell.
+func codeLang(contentTypeOrLang, code string) string {
+	lang := strings.ToLower(strings.TrimSpace(strings.Split(contentTypeOrLang, ";")[0]))
+	if _, subtype, ok := strings.Cut(lang, "/"); ok {
+		lang = subtype
+		if _, suffix, ok := strings.Cut(subtype, "+"); ok {
+			lang = suffix
+		}
+	}
+	switch lang {
+	case "json", "xml", "html", "javascript", "graphql", "csv", "yaml":
+		return lang
+	case "x-yaml":
+		return "yaml"
+	case "plain", "text":
+		return "text"
+	}
+
+	code = strings.TrimSpace(code)
+	switch {
+	case len(code) == 0:
+		return ""
+	case json.Valid([]byte(code)):
+		return "json"
+	case strings.HasPrefix(strings.ToLower(code), "<!doctype html"), strings.HasPrefix(strings.ToLower(code), "<html"):
+		return "html"
+	case strings.HasPrefix(code, "<"):
+		return "xml"
+	}
+	return ""
+}
+
+// indent puts the given number of spaces before each line of s that isn't empty.
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if len(line) > 0 {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrap breaks the lines of s between words so that each line is at most width
+// characters long where possible. Words longer than the width are not broken, and
+// existing line breaks are kept.
+func wrap(width int, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		var b strings.Builder
+		lineLen := 0
+		for _, word := range strings.Fields(line) {
+			wordLen := utf8.RuneCountInString(word)
+			if lineLen > 0 && lineLen+1+wordLen > width {
+				b.WriteString("\n")
+				lineLen = 0
+			} else if lineLen > 0 {
+				b.WriteString(" ")
+				lineLen++
+			}
+			b.WriteString(word)
+			lineLen += wordLen
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
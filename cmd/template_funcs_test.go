@@ -0,0 +1,369 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStringFuncs(t *testing.T) {
+	tests := []struct {
+		name, got, want string
+	}{
+		{"title", title("get all accounts"), "Get All Accounts"},
+		{"title with dashes", title("x-request-id"), "X-Request-Id"},
+		{"trimPrefix", trimPrefix("/v1", "/v1/accounts"), "/accounts"},
+		{"trimPrefix without prefix", trimPrefix("/v2", "/v1/accounts"), "/v1/accounts"},
+		{"trimSuffix", trimSuffix(".json", "api.json"), "api"},
+		{"replace", replace(" ", "-", "get all accounts"), "get-all-accounts"},
+		{"escapeMarkdown", escapeMarkdown("*not* [a link](x) #1 a|b"), "\\*not\\* \\[a link\\]\\(x\\) \\#1 a\\|b"},
+		{"escapeMarkdown plain", escapeMarkdown("plain text"), "plain text"},
+		{"indent", indent(4, "a\n\nb"), "    a\n\n    b"},
+		{"wrap", wrap(10, "the quick brown fox jumps"), "the quick\nbrown fox\njumps"},
+		{"wrap long word", wrap(5, "a extraordinary b"), "a\nextraordinary\nb"},
+		{"wrap keeps line breaks", wrap(80, "one\ntwo  three"), "one\ntwo three"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.got != test.want {
+				t.Errorf("got %q, want %q", test.got, test.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	got := split("/", "v1/accounts")
+	if want := []any{"v1", "accounts"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("split(\"/\", \"v1/accounts\") = %v, want %v", got, want)
+	}
+}
+
+func TestRegexFuncs(t *testing.T) {
+	if ok, err := regexMatch(`^/v\d+/`, "/v1/accounts"); err != nil || !ok {
+		t.Errorf("regexMatch = %v, %v, want true, nil", ok, err)
+	}
+	if ok, err := regexMatch(`^/v\d+/`, "/accounts"); err != nil || ok {
+		t.Errorf("regexMatch = %v, %v, want false, nil", ok, err)
+	}
+	if got, err := regexReplace(`:(\w+)`, "{$1}", "/accounts/:id/events/:eventId"); err != nil || got != "/accounts/{id}/events/{eventId}" {
+		t.Errorf("regexReplace = %q, %v, want %q, nil", got, err, "/accounts/{id}/events/{eventId}")
+	}
+	if _, err := regexMatch(`(`, "x"); err == nil {
+		t.Error("regexMatch with an invalid pattern returned nil error, want non-nil error")
+	}
+	if _, err := regexReplace(`(`, "", "x"); err == nil {
+		t.Error("regexReplace with an invalid pattern returned nil error, want non-nil error")
+	}
+}
+
+func TestDefaultAndCoalesce(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"default with nil", defaultValue("none", nil), "none"},
+		{"default with empty string", defaultValue("none", ""), "none"},
+		{"default with empty list", defaultValue("none", []any{}), "none"},
+		{"default with zero", defaultValue(1.0, 0.0), 1.0},
+		{"default with value", defaultValue("none", "value"), "value"},
+		{"coalesce", coalesce(nil, "", "first", "second"), "first"},
+		{"coalesce all empty", coalesce(nil, ""), nil},
+		{"coalesce nothing", coalesce(), nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.got != test.want {
+				t.Errorf("got %v, want %v", test.got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	tests := []struct {
+		value any
+		want  bool
+	}{
+		{nil, true},
+		{"", true},
+		{false, true},
+		{0.0, true},
+		{[]any{}, true},
+		{map[string]any{}, true},
+		{(*int)(nil), true},
+		{"x", false},
+		{true, false},
+		{1.0, false},
+		{[]any{nil}, false},
+		{map[string]any{"a": 1}, false},
+	}
+
+	for _, test := range tests {
+		if got := isEmpty(test.value); got != test.want {
+			t.Errorf("isEmpty(%#v) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestDictAndList(t *testing.T) {
+	got, err := dict("name", "accounts", "level", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"name": "accounts", "level": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dict = %v, want %v", got, want)
+	}
+	if _, err := dict("name"); err == nil {
+		t.Error("dict with an odd number of arguments returned nil error, want non-nil error")
+	}
+	if _, err := dict(1, "one"); err == nil {
+		t.Error("dict with a number key returned nil error, want non-nil error")
+	}
+
+	if got, want := list("a", 1), []any{"a", 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("list = %v, want %v", got, want)
+	}
+	if got := list(); got == nil || len(got) != 0 {
+		t.Errorf("list() = %#v, want an empty list", got)
+	}
+}
+
+func TestSortByAndGroupBy(t *testing.T) {
+	items := []any{
+		map[string]any{"name": "b", "request": map[string]any{"method": "POST"}, "code": 201.0},
+		map[string]any{"name": "folder"},
+		map[string]any{"name": "c", "request": map[string]any{"method": "GET"}, "code": 20.0},
+		map[string]any{"name": "a", "request": map[string]any{"method": "POST"}, "code": 100.0},
+	}
+	names := func(items []any) string {
+		var names []string
+		for _, item := range items {
+			names = append(names, item.(map[string]any)["name"].(string))
+		}
+		return strings.Join(names, ",")
+	}
+
+	tests := []struct {
+		path, want string
+	}{
+		{"name", "a,b,c,folder"},
+		{"request.method", "c,b,a,folder"},
+		{"code", "c,a,b,folder"},
+	}
+	for _, test := range tests {
+		t.Run("sortBy "+test.path, func(t *testing.T) {
+			sorted, err := sortBy(test.path, items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(sorted); got != test.want {
+				t.Errorf("sortBy(%q, items) = %s, want %s", test.path, got, test.want)
+			}
+		})
+	}
+	if got := names(items); got != "b,folder,c,a" {
+		t.Errorf("sortBy changed the original items' order to %s", got)
+	}
+	if _, err := sortBy("name", "not a list"); err == nil {
+		t.Error("sortBy with a string returned nil error, want non-nil error")
+	}
+
+	groups, err := groupBy("request.method", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, g := range groups {
+		group := g.(map[string]any)
+		got = append(got, group["key"].(string)+":"+names(group["items"].([]any)))
+	}
+	if want := "POST:b,a :folder GET:c"; strings.Join(got, " ") != want {
+		t.Errorf("groupBy = %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestJSONFuncs(t *testing.T) {
+	value := map[string]any{"name": "accounts", "ids": []any{1.0, 2.0}}
+	if got, err := toJSON(value); err != nil || got != `{"ids":[1,2],"name":"accounts"}` {
+		t.Errorf("toJson = %s, %v", got, err)
+	}
+	if got, err := toPrettyJSON([]any{1.0}); err != nil || got != "[\n    1\n]" {
+		t.Errorf("toPrettyJson = %q, %v", got, err)
+	}
+	decoded, err := fromJSON(`{"name": "accounts", "ids": [1, 2]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, value) {
+		t.Errorf("fromJson = %v, want %v", decoded, value)
+	}
+	if _, err := fromJSON("{"); err == nil {
+		t.Error("fromJson with invalid JSON returned nil error, want non-nil error")
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	item := map[string]any{
+		"request": map[string]any{
+			"url": map[string]any{"path": []any{"v1", "accounts"}},
+		},
+	}
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"request.url.path.1", "accounts"},
+		{"$.request.url.path.0", "v1"},
+		{".request.url.path.0", "v1"},
+		{"request.url.path.2", nil},
+		{"request.url.path.x", nil},
+		{"request.missing", nil},
+		{"request.url.path.0.deeper", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := jsonPath(test.path, item); got != test.want {
+				t.Errorf("jsonPath(%q, item) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+	if got := jsonPath("", item); !reflect.DeepEqual(got, item) {
+		t.Errorf("jsonPath(\"\", item) = %v, want the item", got)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	tests := []struct {
+		layout string
+		date   any
+		want   string
+	}{
+		{"2006-01-02", "2023-07-04T12:30:00Z", "2023-07-04"},
+		{"Jan 2, 2006", "Tue, 04 Jul 2023 12:30:00 GMT", "Jul 4, 2023"},
+		{"02/01/2006", "2023-07-04", "04/07/2023"},
+		{"2006-01-02 15:04", 1688473800.0, "2023-07-04 12:30"},
+		{"2006", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), "2023"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			got, err := formatDate(test.layout, test.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("formatDate(%q, %v) = %q, want %q", test.layout, test.date, got, test.want)
+			}
+		})
+	}
+	if _, err := formatDate("2006", "yesterday"); err == nil {
+		t.Error("formatDate with an unknown date format returned nil error, want non-nil error")
+	}
+	if _, err := formatDate("2006", true); err == nil {
+		t.Error("formatDate with a bool returned nil error, want non-nil error")
+	}
+}
+
+func TestEscapeFuncs(t *testing.T) {
+	tests := []struct {
+		name, got, want string
+	}{
+		{"escapeTableCell pipe", escapeTableCell("a | b"), "a \\| b"},
+		{"escapeTableCell escaped pipe", escapeTableCell("a \\| b"), "a \\| b"},
+		{"escapeTableCell escaped backslash", escapeTableCell("a \\\\| b"), "a \\\\\\| b"},
+		{"escapeTableCell line breaks", escapeTableCell("one\r\ntwo\nthree\n"), "one<br>two<br>three"},
+		{"escapeTableCell keeps formatting", escapeTableCell("*bold* `code`"), "*bold* `code`"},
+		{"escapeTableCell number", escapeTableCell(200.0), "200"},
+		{"escapeTableCell nil", escapeTableCell(nil), ""},
+		{"escapeTableCell after escapeMarkdown", escapeTableCell(escapeMarkdown("a|b")), "a\\|b"},
+		{"escapeHTML", escapeHTML(`<b>"Tom & Jerry"</b>`), "&lt;b&gt;&#34;Tom &amp; Jerry&#34;&lt;/b&gt;"},
+		{"escapeAttr", escapeAttr("it's\r\n<ok>"), "it&#39;s&#13;&#10;&lt;ok&gt;"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.got != test.want {
+				t.Errorf("got %q, want %q", test.got, test.want)
+			}
+		})
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	tests := []struct {
+		code, want string
+	}{
+		{"plain", "```"},
+		{"`inline`", "```"},
+		{"```go\n```", "````"},
+		{"`````", "``````"},
+	}
+
+	for _, test := range tests {
+		if got := codeFence(test.code); got != test.want {
+			t.Errorf("codeFence(%q) = %q, want %q", test.code, got, test.want)
+		}
+	}
+}
+
+func TestCodeLang(t *testing.T) {
+	tests := []struct {
+		contentTypeOrLang, code, want string
+	}{
+		{"application/json; charset=utf-8", "", "json"},
+		{"application/problem+json", "", "json"},
+		{"text/html", "", "html"},
+		{"application/x-yaml", "", "yaml"},
+		{"text/plain", "", "text"},
+		{"json", "", "json"},
+		{"", `{"a": 1}`, "json"},
+		{"", "<!DOCTYPE html><html></html>", "html"},
+		{"", "<note></note>", "xml"},
+		{"", "plain text", ""},
+		{"application/octet-stream", "", ""},
+	}
+
+	for _, test := range tests {
+		if got := codeLang(test.contentTypeOrLang, test.code); got != test.want {
+			t.Errorf("codeLang(%q, %q) = %q, want %q", test.contentTypeOrLang, test.code, got, test.want)
+		}
+	}
+}
+
+func TestLibraryFuncsInTemplate(t *testing.T) {
+	collection, err := readCollection("../samples/calendar-API.postman_collection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmplStr := `{{range sortBy "name" .item}}{{.name | replace " " "-" | upper}}
+{{end}}{{with dict "title" (.info.name | title)}}{{.title}}{{end}}
+{{jsonPath "info.missing" . | default "none"}}`
+	var b strings.Builder
+	set := tmplSet{sources: []tmplSource{{"test", tmplStr}}}
+	if err := executeTmplSet(collection, &b, set, newFuncMap(nil, headingStrategy{}), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "DELETE-ACCOUNT\nEDIT-ACCOUNT\nEMPTY-FOLDER\nGET-ENDPOINTS\nPOST-ENDPOINTS\nCalendar API\nnone"
+	if got := b.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
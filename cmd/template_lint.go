@@ -0,0 +1,439 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// dataModel describes a value in the data that templates receive, which is a Postman
+// collection in the Collection v2.1 format with the properties pm2md adds.
+type dataModel struct {
+	// name describes the value in messages, such as "a request".
+	name string
+
+	// fields are an object's fields by name. They are nil if the value isn't an object.
+	fields map[string]*dataModel
+
+	// elem describes a list's elements. It's nil if the value isn't a list.
+	elem *dataModel
+
+	// open is true for objects whose fields aren't checked, such as auth settings.
+	open bool
+}
+
+// objectModel creates the model of an object with the given fields.
+func objectModel(name string, fields map[string]*dataModel) *dataModel {
+	return &dataModel{name: name, fields: fields}
+}
+
+// listModel creates the model of a list of the given elements.
+func listModel(name string, elem *dataModel) *dataModel {
+	return &dataModel{name: name, elem: elem}
+}
+
+// collectionModel is the model of the data that templates receive.
+var collectionModel = newCollectionModel()
+
+// newCollectionModel creates the model of the data that templates receive.
+func newCollectionModel() *dataModel {
+	text := &dataModel{name: "a text value"}
+	number := &dataModel{name: "a number"}
+	boolean := &dataModel{name: "a boolean"}
+	open := &dataModel{name: "an object", open: true}
+	description := objectModel("a description", map[string]*dataModel{
+		"content": text,
+		"type":    text,
+		"version": open,
+	})
+	texts := listModel("a list of text values", text)
+	keyValue := func(name string, extraFields ...string) *dataModel {
+		fields := map[string]*dataModel{
+			"key":         text,
+			"value":       text,
+			"disabled":    boolean,
+			"description": description,
+		}
+		for _, field := range extraFields {
+			fields[field] = text
+		}
+		return objectModel(name, fields)
+	}
+	variable := keyValue("a variable", "id", "type", "name", "system")
+	header := keyValue("a header", "type")
+
+	url := objectModel("a URL", map[string]*dataModel{
+		"raw":      text,
+		"protocol": text,
+		"host":     texts,
+		"path":     listModel("a list of path segments", open),
+		"port":     text,
+		"query":    listModel("a list of query parameters", keyValue("a query parameter")),
+		"hash":     text,
+		"variable": listModel("a list of path variables", variable),
+	})
+	body := objectModel("a request body", map[string]*dataModel{
+		"mode":       text,
+		"raw":        text,
+		"graphql":    open,
+		"urlencoded": listModel("a list of form fields", keyValue("a form field", "type")),
+		"formdata":   listModel("a list of form fields", keyValue("a form field", "type", "src", "contentType")),
+		"file":       open,
+		"options":    open,
+		"disabled":   boolean,
+	})
+	request := objectModel("a request", map[string]*dataModel{
+		"url":         url,
+		"auth":        open,
+		"proxy":       open,
+		"certificate": open,
+		"method":      text,
+		"description": description,
+		"header":      listModel("a list of headers", header),
+		"body":        body,
+	})
+	cookie := objectModel("a cookie", map[string]*dataModel{
+		"domain":     text,
+		"expires":    text,
+		"maxAge":     text,
+		"hostOnly":   boolean,
+		"httpOnly":   boolean,
+		"name":       text,
+		"path":       text,
+		"secure":     boolean,
+		"session":    boolean,
+		"value":      text,
+		"extensions": open,
+	})
+	response := objectModel("a sample response", map[string]*dataModel{
+		"id":                       text,
+		"name":                     text,
+		"originalRequest":          request,
+		"responseTime":             number,
+		"timings":                  open,
+		"header":                   listModel("a list of headers", header),
+		"cookie":                   listModel("a list of cookies", cookie),
+		"body":                     text,
+		"status":                   text,
+		"code":                     number,
+		"_postman_previewlanguage": text,
+		"level":                    number,
+	})
+	event := objectModel("an event", map[string]*dataModel{
+		"id":     text,
+		"listen": text,
+		"script": objectModel("a script", map[string]*dataModel{
+			"id":   text,
+			"type": text,
+			"exec": texts,
+			"src":  open,
+			"name": text,
+		}),
+		"disabled": boolean,
+	})
+	events := listModel("a list of events", event)
+	variables := listModel("a list of variables", variable)
+
+	item := objectModel("an item", map[string]*dataModel{
+		"id":                      text,
+		"name":                    text,
+		"description":             description,
+		"variable":                variables,
+		"event":                   events,
+		"request":                 request,
+		"response":                listModel("a list of sample responses", response),
+		"protocolProfileBehavior": open,
+		"auth":                    open,
+		"level":                   number,
+	})
+	items := listModel("a list of items", item)
+	item.fields["item"] = items
+
+	return objectModel("the collection", map[string]*dataModel{
+		"info": objectModel("the collection's info", map[string]*dataModel{
+			"name":             text,
+			"_postman_id":      text,
+			"description":      description,
+			"version":          open,
+			"schema":           text,
+			"_exporter_id":     text,
+			"_collection_link": text,
+		}),
+		"item":                    items,
+		"event":                   events,
+		"variable":                variables,
+		"auth":                    open,
+		"protocolProfileBehavior": open,
+		"toc": listModel("the table of contents", objectModel("a table of contents entry", map[string]*dataModel{
+			"name":        text,
+			"link":        text,
+			"level":       number,
+			"indent":      text,
+			"number":      text,
+			"method":      text,
+			"path":        text,
+			"description": text,
+		})),
+	})
+}
+
+// tmplProblem is a problem found in a template.
+type tmplProblem struct {
+	File    string
+	Line    int
+	Col     int
+	Message string
+}
+
+// tmplLinter checks the fields and template names that templates use. A nil model
+// means the value is unknown, such as a function's result, so it isn't checked.
+type tmplLinter struct {
+	root     *template.Template
+	problems []tmplProblem
+
+	// visited has the names of the templates that have been checked and the models of
+	// the values they were checked with.
+	visited map[string][]*dataModel
+}
+
+// lintTmplSet parses a template set and checks the fields that its templates use
+// against the collection data model, starting from the template that is executed. It
+// also reports `template` actions with undefined template names. Templates that aren't
+// used are only checked for undefined template names.
+func lintTmplSet(set tmplSet) ([]tmplProblem, error) {
+	root, err := set.parse(parseFuncs, nil)
+	if err != nil {
+		return nil, err
+	}
+	l := tmplLinter{root: root, visited: make(map[string][]*dataModel)}
+	l.lintTemplate(root.Name(), collectionModel)
+
+	var unused []string
+	for _, tmpl := range root.Templates() {
+		if _, ok := l.visited[tmpl.Name()]; !ok && tmpl.Tree != nil {
+			unused = append(unused, tmpl.Name())
+		}
+	}
+	slices.Sort(unused)
+	for _, name := range unused {
+		l.lintTemplate(name, nil)
+	}
+
+	slices.SortFunc(l.problems, func(a, b tmplProblem) int {
+		if a.File != b.File {
+			return cmp.Compare(a.File, b.File)
+		}
+		if a.Line != b.Line {
+			return cmp.Compare(a.Line, b.Line)
+		}
+		return cmp.Compare(a.Col, b.Col)
+	})
+	return slices.Compact(l.problems), nil
+}
+
+// lintTemplate checks the template with the given name executed with a value of the
+// given model, unless it has been checked with that model already.
+func (l *tmplLinter) lintTemplate(name string, dot *dataModel) {
+	if slices.Contains(l.visited[name], dot) {
+		return
+	}
+	l.visited[name] = append(l.visited[name], dot)
+	tmpl := l.root.Lookup(name)
+	if tmpl == nil || tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return
+	}
+	vars := map[string]*dataModel{"$": dot}
+	l.lintNode(tmpl.Tree, tmpl.Tree.Root, dot, vars)
+}
+
+// report adds a problem found at a node.
+func (l *tmplLinter) report(tree *parse.Tree, node parse.Node, format string, args ...any) {
+	location, _ := tree.ErrorContext(node)
+	problem := tmplProblem{File: location, Message: fmt.Sprintf(format, args...)}
+	// The location is like "name:line:col", and the name can have colons.
+	parts := strings.Split(location, ":")
+	if len(parts) >= 3 {
+		problem.File = strings.Join(parts[:len(parts)-2], ":")
+		problem.Line, _ = strconv.Atoi(parts[len(parts)-2])
+		problem.Col, _ = strconv.Atoi(parts[len(parts)-1])
+	}
+	l.problems = append(l.problems, problem)
+}
+
+// lintNode checks a node and the nodes in it.
+func (l *tmplLinter) lintNode(tree *parse.Tree, node parse.Node, dot *dataModel, vars map[string]*dataModel) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.lintNode(tree, child, dot, vars)
+		}
+	case *parse.ActionNode:
+		l.lintPipe(tree, n.Pipe, dot, vars)
+	case *parse.IfNode:
+		l.lintPipe(tree, n.Pipe, dot, vars)
+		l.lintNode(tree, n.List, dot, maps.Clone(vars))
+		l.lintNode(tree, n.ElseList, dot, maps.Clone(vars))
+	case *parse.WithNode:
+		model := l.lintPipe(tree, n.Pipe, dot, vars)
+		l.lintNode(tree, n.List, model, maps.Clone(vars))
+		l.lintNode(tree, n.ElseList, dot, maps.Clone(vars))
+	case *parse.RangeNode:
+		rangeVars := maps.Clone(vars)
+		model := l.lintPipe(tree, n.Pipe, dot, rangeVars)
+		var elem *dataModel
+		if model != nil {
+			elem = model.elem
+		}
+		if decls := n.Pipe.Decl; len(decls) == 1 {
+			rangeVars[decls[0].Ident[0]] = elem
+		} else if len(decls) == 2 {
+			rangeVars[decls[0].Ident[0]] = nil
+			rangeVars[decls[1].Ident[0]] = elem
+		}
+		l.lintNode(tree, n.List, elem, rangeVars)
+		l.lintNode(tree, n.ElseList, dot, maps.Clone(vars))
+	case *parse.TemplateNode:
+		var model *dataModel
+		if n.Pipe != nil {
+			model = l.lintPipe(tree, n.Pipe, dot, vars)
+		}
+		if tmpl := l.root.Lookup(n.Name); tmpl == nil || tmpl.Tree == nil {
+			l.report(tree, n, "template %q is not defined", n.Name)
+			return
+		}
+		l.lintTemplate(n.Name, model)
+	}
+}
+
+// lintPipe checks a pipeline and returns the model of its result. Declared variables
+// are added to vars.
+func (l *tmplLinter) lintPipe(tree *parse.Tree, pipe *parse.PipeNode, dot *dataModel, vars map[string]*dataModel) *dataModel {
+	if pipe == nil {
+		return nil
+	}
+	var model *dataModel
+	for i, command := range pipe.Cmds {
+		model = l.lintCommand(tree, command, dot, vars, model, i > 0)
+	}
+	if len(pipe.Decl) == 1 && !pipe.IsAssign {
+		vars[pipe.Decl[0].Ident[0]] = model
+	}
+	return model
+}
+
+// lintCommand checks a command of a pipeline and returns the model of its result. If
+// the command is piped, the previous command's result is its final argument.
+func (l *tmplLinter) lintCommand(tree *parse.Tree, command *parse.CommandNode, dot *dataModel, vars map[string]*dataModel, piped *dataModel, isPiped bool) *dataModel {
+	models := make([]*dataModel, len(command.Args))
+	for i, arg := range command.Args {
+		models[i] = l.lintArg(tree, arg, dot, vars)
+	}
+	if len(command.Args) == 0 {
+		return nil
+	}
+	fn, ok := command.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return models[0]
+	}
+	last := piped
+	if !isPiped {
+		last = nil
+		if len(models) > 1 {
+			last = models[len(models)-1]
+		}
+	}
+	switch fn.Ident {
+	case "sortBy":
+		return last
+	case "groupBy":
+		if last == nil {
+			return nil
+		}
+		group := objectModel("a group", map[string]*dataModel{
+			"key":   {name: "a text value"},
+			"items": listModel(last.name, last.elem),
+		})
+		return listModel("a list of groups", group)
+	}
+	return nil
+}
+
+// lintArg checks an argument of a command and returns the model of its value.
+func (l *tmplLinter) lintArg(tree *parse.Tree, arg parse.Node, dot *dataModel, vars map[string]*dataModel) *dataModel {
+	switch n := arg.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return l.lintField(tree, n, dot, "", n.Ident)
+	case *parse.VariableNode:
+		model, ok := vars[n.Ident[0]]
+		if !ok {
+			return nil
+		}
+		return l.lintField(tree, n, model, n.Ident[0], n.Ident[1:])
+	case *parse.ChainNode:
+		model := l.lintArg(tree, n.Node, dot, vars)
+		return l.lintField(tree, n, model, "("+n.Node.String()+")", n.Field)
+	case *parse.PipeNode:
+		return l.lintPipe(tree, n, dot, vars)
+	}
+	return nil
+}
+
+// lintField checks a chain of field names starting from a value of the given model and
+// returns the model of the last field's value.
+func (l *tmplLinter) lintField(tree *parse.Tree, node parse.Node, model *dataModel, prefix string, idents []string) *dataModel {
+	path := prefix
+	for _, ident := range idents {
+		path += "." + ident
+		if model == nil || model.open {
+			return nil
+		}
+		field, ok := model.fields[ident]
+		switch {
+		case ok:
+			model = field
+		case model.elem != nil:
+			l.report(tree, node, "unknown field %s: %s has no fields, so use range to get its elements", path, model.name)
+			return nil
+		case model.fields == nil:
+			l.report(tree, node, "unknown field %s: %s has no fields", path, model.name)
+			return nil
+		default:
+			l.report(tree, node, "unknown field %s: %s has no field %q", path, model.name, ident)
+			return nil
+		}
+	}
+	return model
+}
+
+// writeTmplProblems writes one line per problem, such as
+// `custom.tmpl:12:8: unknown field .request.bdy: a request has no field "bdy"`.
+func writeTmplProblems(w io.Writer, problems []tmplProblem) {
+	for _, p := range problems {
+		fmt.Fprintf(w, "%s:%d:%d: %s\n", p.File, p.Line, p.Col, p.Message)
+	}
+}
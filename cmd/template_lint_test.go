@@ -0,0 +1,211 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// lintTmplText lints one template file's text and returns the problems as text.
+func lintTmplText(t *testing.T, text string) []string {
+	t.Helper()
+	problems, err := lintTmplSet(tmplSet{sources: []tmplSource{{"custom.tmpl", text}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Col, p.Message))
+	}
+	return got
+}
+
+func TestLintTmplSet(t *testing.T) {
+	tests := []struct {
+		name, text string
+		want       []string
+	}{
+		{
+			"known fields",
+			"{{.info.name}}{{range .item}}{{.request.body.raw}}{{range .response}}{{.code}}{{end}}{{end}}",
+			nil,
+		},
+		{
+			"unknown field",
+			"# {{.info.name}}\n{{range .item}}{{.request.bdy.raw}}{{end}}",
+			[]string{`custom.tmpl:2:25: unknown field .request.bdy: a request has no field "bdy"`},
+		},
+		{
+			"field of a list",
+			"{{.item.name}}",
+			[]string{"custom.tmpl:1:7: unknown field .item.name: a list of items has no fields, so use range to get its elements"},
+		},
+		{
+			"field of text",
+			"{{.info.name.first}}",
+			[]string{"custom.tmpl:1:7: unknown field .info.name.first: a text value has no fields"},
+		},
+		{
+			"nested items",
+			"{{range .item}}{{range .item}}{{range .item}}{{.nme}}{{end}}{{end}}{{end}}",
+			[]string{`custom.tmpl:1:47: unknown field .nme: an item has no field "nme"`},
+		},
+		{
+			"with",
+			"{{with .info}}{{.name}}{{.title}}{{else}}{{.title}}{{end}}",
+			[]string{
+				`custom.tmpl:1:25: unknown field .title: the collection's info has no field "title"`,
+				`custom.tmpl:1:43: unknown field .title: the collection has no field "title"`,
+			},
+		},
+		{
+			"variables",
+			"{{$info := .info}}{{range $i, $item := .item}}{{$item.nam}}{{$.inf}}{{$info.nme}}{{end}}",
+			[]string{
+				`custom.tmpl:1:53: unknown field $item.nam: an item has no field "nam"`,
+				`custom.tmpl:1:62: unknown field $.inf: the collection has no field "inf"`,
+				`custom.tmpl:1:75: unknown field $info.nme: the collection's info has no field "nme"`,
+			},
+		},
+		{
+			"function arguments",
+			`{{join .request.url.path "/"}}{{range .item}}{{join .request.url.pth "/"}}{{end}}`,
+			[]string{
+				`custom.tmpl:1:15: unknown field .request: the collection has no field "request"`,
+				`custom.tmpl:1:60: unknown field .request.url.pth: a URL has no field "pth"`,
+			},
+		},
+		{
+			"sortBy and groupBy",
+			`{{range sortBy "name" .item}}{{.nme}}{{end}}{{range groupBy "name" .toc}}{{.key}}{{range .items}}{{.lnk}}{{end}}{{end}}`,
+			[]string{
+				`custom.tmpl:1:31: unknown field .nme: an item has no field "nme"`,
+				`custom.tmpl:1:99: unknown field .lnk: a table of contents entry has no field "lnk"`,
+			},
+		},
+		{
+			"table of contents descriptions",
+			`{{range .toc}}{{.description}}{{.description.content}}{{end}}`,
+			[]string{"custom.tmpl:1:44: unknown field .description.content: a text value has no fields"},
+		},
+		{
+			"unknown values aren't checked",
+			`{{with fromJson .info.description}}{{.anything}}{{end}}{{range .item}}{{.auth.anything}}{{end}}`,
+			nil,
+		},
+		{
+			"templates",
+			`{{define "endpoint"}}{{.request.mthd}}{{end}}{{range .item}}{{template "endpoint" .}}{{end}}{{template "missing" .}}`,
+			[]string{
+				`custom.tmpl:1:31: unknown field .request.mthd: a request has no field "mthd"`,
+				`custom.tmpl:1:103: template "missing" is not defined`,
+			},
+		},
+		{
+			"unused templates",
+			`{{define "unused"}}{{.whatever}}{{template "missing"}}{{end}}`,
+			[]string{`custom.tmpl:1:43: template "missing" is not defined`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := lintTmplText(t, test.text)
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("lint problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLintTmplSetParseError(t *testing.T) {
+	_, err := lintTmplSet(tmplSet{sources: []tmplSource{{"custom.tmpl", "{{.info.name"}}})
+	if err == nil || !strings.Contains(err.Error(), "custom.tmpl:1") {
+		t.Errorf("lintTmplSet with a parse error returned %v, want an error with the file and line", err)
+	}
+}
+
+func TestTemplatesLintProblemCount(t *testing.T) {
+	dir := writeTmplFiles(t, map[string]string{
+		"one.tmpl": "{{.nme}}",
+		"two.tmpl": "{{.nme}}{{.inf}}",
+	})
+	tests := []struct {
+		fileName, wantErr string
+	}{
+		{"one.tmpl", "1 problem found"},
+		{"two.tmpl", "2 problems found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fileName, func(t *testing.T) {
+			err := templatesLintRunFunc(&cobra.Command{}, []string{filepath.Join(dir, test.fileName)})
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("templates lint returned %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestLintGalleryTemplates(t *testing.T) {
+	for _, name := range galleryTemplateNames() {
+		t.Run(name, func(t *testing.T) {
+			set, err := loadTmplSet([]string{"@" + name}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems, err := lintTmplSet(set)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(problems) > 0 {
+				t.Errorf("@%s has problems: %+v", name, problems)
+			}
+		})
+	}
+}
+
+func TestRenderTextStrict(t *testing.T) {
+	tmplPath := filepath.Join(writeTmplFiles(t, map[string]string{
+		"typo.tmpl": "{{range .item}}{{.nme}}{{end}}",
+	}), "typo.tmpl")
+	tests := []struct {
+		strict  bool
+		wantErr string
+	}{
+		{false, ""},
+		{true, `map has no entry for key "nme"`},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("strict=%v", test.strict), func(t *testing.T) {
+			collection, err := readCollection("../samples/calendar-API.postman_collection.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = renderText(collection, &strings.Builder{}, renderOptions{tmplPaths: []string{tmplPath}, strict: test.strict})
+			if len(test.wantErr) == 0 && err != nil {
+				t.Errorf("renderText returned %v, want nil error", err)
+			} else if len(test.wantErr) > 0 && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Errorf("renderText returned %v, want an error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// templatePathEnv is the environment variable with folders of partials to add to the
+// template search path, separated like the folders in PATH.
+const templatePathEnv = "PM2MD_TEMPLATE_PATH"
+
+// tmplSource is the name and text of one template file.
+type tmplSource struct {
+	name string
+	text string
+}
+
+// tmplSet is the template files that make up one template. Partials are parsed first,
+// so their blocks are available to all the sources but never replace the sources'
+// blocks. Sources are parsed in order, and each `define` block replaces any earlier
+// block with the same name. The output is the text outside of `define` blocks of the
+// last source that has any.
+type tmplSet struct {
+	partials []tmplSource
+	sources  []tmplSource
+}
+
+// loadTmplSet loads the template files at the given paths and the partials in the
+// folders of the given search path and of the PM2MD_TEMPLATE_PATH environment variable.
+// Each template path is a built-in template such as "@table", a template file, or a
+// folder of template files, which are loaded in alphabetical order. Empty paths are
+// ignored. If none of the template files has text outside of `define` blocks, the
+// default template is loaded first so that the files can replace its blocks.
+func loadTmplSet(tmplPaths, searchPath []string) (tmplSet, error) {
+	var set tmplSet
+	searchPath = append(slices.Clone(searchPath), filepath.SplitList(os.Getenv(templatePathEnv))...)
+	for _, dir := range searchPath {
+		if len(dir) == 0 {
+			continue
+		}
+		partials, err := loadTmplDir(dir)
+		if err != nil {
+			return tmplSet{}, err
+		}
+		for _, partial := range partials {
+			if hasBody, err := tmplHasBody(partial); err != nil {
+				return tmplSet{}, err
+			} else if hasBody {
+				return tmplSet{}, fmt.Errorf(
+					"the partial %q in the template search path has text outside of define blocks",
+					partial.name,
+				)
+			}
+		}
+		set.partials = append(set.partials, partials...)
+	}
+
+	for _, tmplPath := range tmplPaths {
+		if len(tmplPath) == 0 {
+			continue
+		}
+		if info, err := os.Stat(tmplPath); err == nil && info.IsDir() {
+			sources, err := loadTmplDir(tmplPath)
+			if err != nil {
+				return tmplSet{}, err
+			}
+			if len(sources) == 0 {
+				return tmplSet{}, fmt.Errorf("no .tmpl files found in %q", tmplPath)
+			}
+			set.sources = append(set.sources, sources...)
+			continue
+		}
+		source, err := loadTmplSource(tmplPath)
+		if err != nil {
+			return tmplSet{}, err
+		}
+		set.sources = append(set.sources, source)
+	}
+
+	for _, source := range set.sources {
+		if hasBody, err := tmplHasBody(source); err != nil || hasBody {
+			return set, err
+		}
+	}
+	base, err := loadTmplSource("")
+	if err != nil {
+		return tmplSet{}, err
+	}
+	set.sources = append([]tmplSource{base}, set.sources...)
+	return set, nil
+}
+
+// loadTmplSource loads a built-in template such as "@table" or a template file. If the
+// path is empty, the default template is loaded. A file's template name is its path.
+func loadTmplSource(tmplPath string) (tmplSource, error) {
+	tmplName, tmplStr, err := loadTmpl(tmplPath)
+	if err != nil {
+		return tmplSource{}, err
+	}
+	if len(tmplPath) > 0 && !isGalleryRef(tmplPath) {
+		tmplName = filepath.ToSlash(tmplPath)
+	}
+	return tmplSource{tmplName, tmplStr}, nil
+}
+
+// loadTmplDir loads the .tmpl files in a folder, not including subfolders, in
+// alphabetical order.
+func loadTmplDir(dir string) ([]tmplSource, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var sources []tmplSource
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") {
+			continue
+		}
+		source, err := loadTmplSource(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// tmplHasBody reports whether a template file has any text outside of `define` blocks
+// other than spaces and comments.
+func tmplHasBody(source tmplSource) (bool, error) {
+	tmpl, err := template.New(source.name).Funcs(parseFuncs).Parse(source.text)
+	if err != nil {
+		return false, fmt.Errorf("template parsing error: %s", err)
+	}
+	return tmpl.Tree != nil && !parse.IsEmptyTree(tmpl.Tree.Root), nil
+}
+
+// parseFuncs has the names of the template functions with stand-in functions for
+// parsing templates before the real functions exist. Only the names matter to the
+// parser.
+var parseFuncs = func() template.FuncMap {
+	funcs := make(template.FuncMap)
+	for name := range newFuncMap(nil, headingStrategy{}) {
+		funcs[name] = func(...any) any { return nil }
+	}
+	return funcs
+}()
+
+// parse parses the template set with the given functions and template options, such as
+// "missingkey=error", and returns the template to execute.
+func (s tmplSet) parse(funcMap template.FuncMap, options []string) (*template.Template, error) {
+	if len(s.sources) == 0 {
+		return nil, fmt.Errorf("no templates to parse")
+	}
+	root := template.New(s.sources[0].name).Funcs(funcMap).Option(options...)
+	for _, source := range append(slices.Clone(s.partials), s.sources...) {
+		tmpl := root
+		if source.name != root.Name() {
+			tmpl = root.New(source.name)
+		}
+		if _, err := tmpl.Parse(source.text); err != nil {
+			return nil, fmt.Errorf("template parsing error: %s", err)
+		}
+	}
+	return root.Lookup(s.mainName()), nil
+}
+
+// parseHTML is like parse, but the returned template escapes values for HTML depending
+// on where in the HTML they are output.
+func (s tmplSet) parseHTML(funcMap template.FuncMap, options []string) (*htmltemplate.Template, error) {
+	if len(s.sources) == 0 {
+		return nil, fmt.Errorf("no templates to parse")
+	}
+	root := htmltemplate.New(s.sources[0].name).Funcs(funcMap).Option(options...)
+	for _, source := range append(slices.Clone(s.partials), s.sources...) {
+		tmpl := root
+		if source.name != root.Name() {
+			tmpl = root.New(source.name)
+		}
+		if _, err := tmpl.Parse(source.text); err != nil {
+			return nil, fmt.Errorf("template parsing error: %s", err)
+		}
+	}
+	return root.Lookup(s.mainName()), nil
+}
+
+// mainName returns the name of the template to execute: the last source that has text
+// outside of `define` blocks.
+func (s tmplSet) mainName() string {
+	main := s.sources[0].name
+	for _, source := range s.sources {
+		if hasBody, _ := tmplHasBody(source); hasBody {
+			main = source.name
+		}
+	}
+	return main
+}
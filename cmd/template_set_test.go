@@ -0,0 +1,210 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTmplFiles creates template files in a new temporary folder and returns the
+// folder's path.
+func writeTmplFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// renderTmplSet renders the calendar API sample collection with the given template
+// paths and template search path.
+func renderTmplSet(t *testing.T, tmplPaths, searchPath []string) (string, error) {
+	t.Helper()
+	collection, err := readCollection("../samples/calendar-API.postman_collection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	err = renderText(collection, &b, renderOptions{tmplPaths: tmplPaths, tmplSearchPath: searchPath})
+	return b.String(), err
+}
+
+func TestTemplateSetOverrides(t *testing.T) {
+	dir := writeTmplFiles(t, map[string]string{
+		"request.tmpl":  `{{define "request"}}` + "\n\nREQUEST {{.request.method}}{{end}}",
+		"table.tmpl":    "{{range .item}}* {{.name}}\n{{end}}",
+		"override.tmpl": `{{define "responses"}}` + "\n\nNO RESPONSES{{end}}",
+		"partial.tmpl":  `{{define "note"}}NOTE{{end}}`,
+		"uses.tmpl":     `{{template "note"}} {{.info.name}}`,
+		"ignored.txt":   "not a template",
+	})
+	partialDir := writeTmplFiles(t, map[string]string{
+		"note.tmpl": `{{define "note"}}PARTIAL NOTE{{end}}`,
+	})
+	envDir := writeTmplFiles(t, map[string]string{
+		"footer.tmpl": `{{define "footer"}}FOOTER{{end}}`,
+	})
+	envFooterTmpl := filepath.Join(writeTmplFiles(t, map[string]string{
+		"footer-user.tmpl": `{{.info.name}} {{template "footer"}}`,
+	}), "footer-user.tmpl")
+	t.Setenv(templatePathEnv, envDir)
+
+	tests := []struct {
+		name        string
+		tmplPaths   []string
+		searchPath  []string
+		wantParts   []string
+		unwantParts []string
+	}{
+		{
+			"override a block of the default template",
+			[]string{filepath.Join(dir, "request.tmpl")},
+			nil,
+			[]string{"# calendar API", "<details open>", "REQUEST POST"},
+			[]string{"sample request body"},
+		},
+		{
+			"override a block of a built-in template",
+			[]string{"@default", filepath.Join(dir, "override.tmpl")},
+			nil,
+			[]string{"# calendar API", "sample request body", "NO RESPONSES"},
+			[]string{"sample response"},
+		},
+		{
+			"last template with a body is executed",
+			[]string{"@default", filepath.Join(dir, "table.tmpl")},
+			nil,
+			[]string{"* POST endpoints\n* empty folder\n"},
+			[]string{"# calendar API"},
+		},
+		{
+			"partial from the search path",
+			[]string{filepath.Join(dir, "uses.tmpl")},
+			[]string{partialDir},
+			[]string{"PARTIAL NOTE calendar API"},
+			nil,
+		},
+		{
+			"source blocks replace partials",
+			[]string{filepath.Join(dir, "partial.tmpl"), filepath.Join(dir, "uses.tmpl")},
+			[]string{partialDir},
+			[]string{"NOTE calendar API"},
+			[]string{"PARTIAL"},
+		},
+		{
+			"partial from the environment variable",
+			[]string{envFooterTmpl},
+			nil,
+			[]string{"calendar API FOOTER"},
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := renderTmplSet(t, test.tmplPaths, test.searchPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.wantParts {
+				if !strings.Contains(got, want) {
+					t.Errorf("output doesn't contain %q:\n%s", want, got)
+				}
+			}
+			for _, unwant := range test.unwantParts {
+				if strings.Contains(got, unwant) {
+					t.Errorf("output contains %q:\n%s", unwant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateSetFolder(t *testing.T) {
+	dir := writeTmplFiles(t, map[string]string{
+		"a-main.tmpl":   `{{.info.name}}: {{template "detail" .}}`,
+		"b-detail.tmpl": `{{define "detail"}}{{len .item}} folders{{end}}`,
+	})
+	got, err := renderTmplSet(t, []string{dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "calendar API: 5 folders"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestTemplateSetErrors(t *testing.T) {
+	dir := writeTmplFiles(t, map[string]string{
+		"body.tmpl": `{{define "note"}}NOTE{{end}}text outside of define blocks`,
+	})
+	emptyDir := t.TempDir()
+	t.Setenv(templatePathEnv, "")
+
+	tests := []struct {
+		name       string
+		tmplPaths  []string
+		searchPath []string
+		wantErr    string
+	}{
+		{"partial with a body", nil, []string{dir}, "has text outside of define blocks"},
+		{"empty template folder", []string{emptyDir}, nil, "no .tmpl files found"},
+		{"missing search path folder", nil, []string{filepath.Join(emptyDir, "missing")}, "no such file"},
+		{"unknown built-in template", []string{"@nonexistent"}, nil, "unknown built-in template"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadTmplSet(test.tmplPaths, test.searchPath)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("loadTmplSet(%q, %q) error = %v, want an error containing %q", test.tmplPaths, test.searchPath, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseConfigTemplateList(t *testing.T) {
+	tests := []struct {
+		name, template string
+		want           TemplateList
+	}{
+		{"one path", "template: docs.tmpl", TemplateList{"docs.tmpl"}},
+		{"list of paths", "template: [\"@default\", overrides.tmpl]", TemplateList{"@default", "overrides.tmpl"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := parseConfig([]byte("targets:\n  docs:\n    input: c.json\n    output: docs.md\n    " + test.template + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := config.Targets["docs"].Template
+			if len(got) != len(test.want) {
+				t.Fatalf("template = %q, want %q", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("template = %q, want %q", got, test.want)
+				}
+			}
+		})
+	}
+}
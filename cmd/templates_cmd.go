@@ -0,0 +1,134 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:     "templates",
+	Aliases: []string{"template"},
+	Short:   "List, export, and check templates",
+	Long: "List, export, and check templates\n\n" +
+		"Any built-in template can be used by name with a leading @, such as\n" +
+		"--template=@table, or exported as a starting point for a custom template.",
+	Example: `  pm2md templates list
+  pm2md templates get table
+  pm2md template lint custom.tmpl
+  pm2md collection.json --template=@table`,
+	Args: cobra.NoArgs,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built-in templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, t := range gallery {
+			fmt.Fprintf(tw, "@%s\t%s\n", t.name, t.description)
+		}
+		return tw.Flush()
+	},
+}
+
+var templatesGetCmd = &cobra.Command{
+	Use:   "get [name [output.tmpl]]",
+	Short: "Export a built-in template",
+	Long: "Export a built-in template\n\n" +
+		"Without an output file, the template is saved to a new file with a unique name\n" +
+		"based on the template's name. Use - as the output file to print the template.",
+	Example: `  pm2md templates get table
+  pm2md templates get @gitbook docs.tmpl
+  pm2md templates get compact -`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: templatesGetRunFunc,
+}
+
+var templatesLintCmd = &cobra.Command{
+	Use:   "lint custom.tmpl...",
+	Short: "Check a template for unknown fields and undefined templates",
+	Long: "Check a template for unknown fields and undefined templates\n\n" +
+		"The fields the template uses, such as .request.body.raw, are checked against the\n" +
+		"data templates receive: a Postman collection in the Collection v2.1 format with the\n" +
+		"properties pm2md adds, such as level and toc, and the templates that template actions\n" +
+		"use must be defined.\n\n" +
+		"The templates can be files, folders, and built-in templates, the same as with\n" +
+		"--template, and partials in --template-path folders can be used. Values that\n" +
+		"functions return aren't checked, except those of sortBy and groupBy.",
+	Example: `  pm2md template lint custom.tmpl
+  pm2md template lint @default overrides.tmpl --template-path=partials`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: templatesLintRunFunc,
+}
+
+// templatesLintRunFunc checks templates, prints any problems, and returns an error if
+// there are any.
+func templatesLintRunFunc(cmd *cobra.Command, args []string) error {
+	set, err := loadTmplSet(args, TemplateSearchPath)
+	if err != nil {
+		return err
+	}
+	problems, err := lintTmplSet(set)
+	if err != nil {
+		return err
+	}
+	writeTmplProblems(os.Stdout, problems)
+	if len(problems) > 0 {
+		cmd.SilenceUsage = true
+		if len(problems) == 1 {
+			return fmt.Errorf("1 problem found")
+		}
+		return fmt.Errorf("%d problems found", len(problems))
+	}
+	return nil
+}
+
+// templatesGetRunFunc saves a built-in template to a file or prints it to stdout.
+func templatesGetRunFunc(cmd *cobra.Command, args []string) error {
+	name := strings.TrimPrefix(args[0], "@")
+	_, tmplStr, err := loadGalleryTmpl(name)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		fileName := exportText(name, ".tmpl", tmplStr)
+		fmt.Fprintf(os.Stderr, "Created %q\n", fileName)
+		return nil
+	}
+
+	destPath := args[1]
+	if destPath == "-" {
+		_, err := os.Stdout.WriteString(tmplStr)
+		return err
+	}
+	if !strings.HasSuffix(destPath, ".tmpl") {
+		return fmt.Errorf("%q must be \"-\" or end with \".tmpl\"", destPath)
+	}
+	if FileExists(destPath) && !ConfirmReplaceExistingFile {
+		return fmt.Errorf("file %q already exists. Run the command again with the --replace flag to confirm replacing it", destPath)
+	}
+	if err := writeFileAtomic(destPath, []byte(tmplStr)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Created %q\n", destPath)
+	return nil
+}
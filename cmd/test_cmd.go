@@ -24,42 +24,41 @@ import (
 )
 
 var testCmd = &cobra.Command{
-	Use:   "test [api.json custom.tmpl expected.md]",
+	Use:   "test [api.json [custom.tmpl] expected.md]",
 	Short: "Test your custom template with expected output",
-	Args:  testArgsFunc,
-	RunE:  testRunFunc,
+	Long: "Test your custom template with expected output\n\n" +
+		"The collection is converted the same way as without the test subcommand, so flags\n" +
+		"such as --template, --template-path, --anchors, --toc-depth, --env, the filters,\n" +
+		"and the sort orders apply. A template argument is used before any --template flags.",
+	Example: `  pm2md test api.json custom.tmpl expected.md
+  pm2md test api.json expected.md --template=base.tmpl --template=overrides.tmpl
+  pm2md test api.json templates/ expected.md --template-path=partials --toc-numbers`,
+	Args: testArgsFunc,
+	RunE: testRunFunc,
 }
 
 // testArgsFunc does some input validation on the `test` subcommand's args and flags.
 func testArgsFunc(cmd *cobra.Command, args []string) error {
-	if len(CustomTmplPath) > 0 {
-		return fmt.Errorf("with the test subcommand, choose a custom template without using the flag")
-	}
-	if err := cobra.ExactArgs(3)(cmd, args); err != nil {
+	if err := cobra.RangeArgs(2, 3)(cmd, args); err != nil {
 		return err
 	}
-	if !strings.HasSuffix(strings.ToLower(args[0]), ".json") {
-		return fmt.Errorf("%q must end with \".json\"", args[0])
+	if !isCollectionPath(args[0]) {
+		return fmt.Errorf("%q must end with \".json\", \".json.gz\", or \".zip\"", args[0])
 	}
-	if !strings.HasSuffix(strings.ToLower(args[1]), ".tmpl") {
-		return fmt.Errorf("%q must end with \".tmpl\"", args[1])
+	if len(args) == 3 && !isGalleryRef(args[1]) && !strings.HasSuffix(strings.ToLower(args[1]), ".tmpl") {
+		if info, err := os.Stat(args[1]); err != nil || !info.IsDir() {
+			return fmt.Errorf("%q must end with \".tmpl\", be a folder, or be a built-in template such as \"@table\"", args[1])
+		}
 	}
-	return nil
+	return testTarget(args).validate()
 }
 
 // testRunFunc parses the `test` subcommand's args and flags, and asserts the given JSON
-// and template result in the given plaintext.
+// and templates result in the given plaintext.
 func testRunFunc(cmd *cobra.Command, args []string) error {
-	jsonPath := args[0]
-	tmplPath := args[1]
-	wantPath := args[2]
-
-	statusRanges, err := parseStatusRanges(Statuses)
-	if err != nil {
-		return err
-	}
+	wantPath := args[len(args)-1]
 
-	err = AssertGenerateNoDiff(jsonPath, tmplPath, wantPath, statusRanges)
+	err := AssertGenerateNoDiff(testTarget(args), wantPath)
 	if err == nil {
 		fmt.Fprintf(os.Stderr, "Perfect match!")
 	} else {
@@ -69,9 +68,23 @@ func testRunFunc(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// testTarget returns the target that the `test` subcommand's args and flags describe.
+// The template arg, if there is one, comes before the templates of any --template flags.
+func testTarget(args []string) Target {
+	target := flagTarget().withOverrides(Target{Input: args[0]})
+	if len(args) == 3 {
+		target.Template = append(TemplateList{args[1]}, target.Template...)
+	}
+	return target
+}
+
 // loadTmpl loads a template's name and the template itself into strings. If the given
-// template path is empty, the default template is used.
+// template path is empty, the default template is used. If it starts with "@", such as
+// "@table", the built-in template with that name is used.
 func loadTmpl(tmplPath string) (tmplName string, tmplStr string, err error) {
+	if isGalleryRef(tmplPath) {
+		return loadGalleryTmpl(tmplPath)
+	}
 	if len(tmplPath) > 0 {
 		tmplBytes, err := os.ReadFile(tmplPath)
 		if err != nil {
@@ -0,0 +1,158 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tocOptions are the settings for a table of contents.
+type tocOptions struct {
+	// maxDepth is the deepest level of items to include. If zero, all levels are
+	// included.
+	maxDepth int
+
+	// numbered is whether each entry has a number such as "1.2".
+	numbered bool
+
+	// showMethods is whether each endpoint's entry has its method and path.
+	showMethods bool
+}
+
+// addTableOfContents adds a "toc" property to the collection. It is a list of entries,
+// one for each item in the order the items appear in the output, and each entry has
+// these properties:
+//
+//   - "name": the item's name
+//   - "link": a relative link path to the item's header, or empty if the header is
+//     too deep and is bold text instead, which can't be linked to
+//   - "level": the item's level, which starts at 1 for the outermost items
+//   - "indent": two spaces for each level after the first, for nesting markdown lists
+//   - "number": the item's number such as "1.2", or empty if numbering is off
+//   - "method" and "path": the endpoint's method and URL path, or empty for folders or
+//     if showing methods is off
+//   - "description": the item's description, or empty if it has none
+//
+// The links are formatted in the given anchor style and assume the headers of the
+// default template: the collection's name, then each item's name, each followed by the
+// headers of the endpoint's sample request body and sample responses, in that order.
+// Items deeper than the max depth are left out of the list but still affect the links
+// of duplicate headers. Headers that the heading strategy makes bold text don't.
+func addTableOfContents(collection map[string]any, style anchorStyle, opts tocOptions, headings headingStrategy) {
+	headerLinks := newHeaderLinker(style)
+	if info, ok := collection["info"].(map[string]any); ok {
+		if name, ok := info["name"].(string); ok {
+			headerLinks.headerPath(name)
+		}
+	}
+	toc := make([]any, 0)
+	if items, ok := collection["item"].([]any); ok {
+		toc = _addTableOfContents(toc, items, 1, "", headerLinks, opts, headings)
+	}
+	collection["toc"] = toc
+}
+
+func _addTableOfContents(toc []any, items []any, level int, numberPrefix string, headerLinks *headerLinker, opts tocOptions, headings headingStrategy) []any {
+	for i, itemAny := range items {
+		item := itemAny.(map[string]any)
+		name := fmt.Sprint(item["name"])
+		link := ""
+		if headings.headerLevel(level) > 0 {
+			link = headerLinks.headerPath(name)
+		}
+		number := fmt.Sprintf("%s%d", numberPrefix, i+1)
+		subItemsAny, isFolder := item["item"]
+
+		if opts.maxDepth == 0 || level <= opts.maxDepth {
+			entry := map[string]any{
+				"name":        name,
+				"link":        link,
+				"level":       level,
+				"indent":      strings.Repeat("  ", level-1),
+				"number":      "",
+				"method":      "",
+				"path":        "",
+				"description": "",
+			}
+			if opts.numbered {
+				entry["number"] = number
+			}
+			if description, ok := item["description"]; ok {
+				entry["description"] = description
+			}
+			if opts.showMethods && !isFolder {
+				entry["method"], entry["path"] = methodAndPath(item)
+			}
+			toc = append(toc, entry)
+		}
+
+		if isFolder {
+			toc = _addTableOfContents(toc, subItemsAny.([]any), level+1, number+".", headerLinks, opts, headings)
+		} else if headings.headerLevel(level+1) > 0 {
+			for _, header := range sampleHeaders(item) {
+				headerLinks.headerPath(header)
+			}
+		}
+	}
+
+	return toc
+}
+
+// sampleHeaders returns the headers that the default template outputs after an
+// endpoint's name: one for its sample request body, if it has one, and one for each of
+// its sample responses.
+func sampleHeaders(endpoint map[string]any) []string {
+	var headers []string
+	if raw, ok := jsonPath("request.body.raw", endpoint).(string); ok && len(raw) > 0 {
+		headers = append(headers, "sample request body")
+	}
+	responses, _ := endpoint["response"].([]any)
+	for _, responseAny := range responses {
+		response, ok := responseAny.(map[string]any)
+		if !ok {
+			continue
+		}
+		if name, ok := response["name"].(string); ok && len(name) > 0 {
+			headers = append(headers, fmt.Sprintf("sample response to %s (status: %v %v)", name, response["code"], response["status"]))
+		} else {
+			headers = append(headers, fmt.Sprintf("sample response (status: %v %v)", response["code"], response["status"]))
+		}
+	}
+	return headers
+}
+
+// methodAndPath returns an endpoint's request method and the path of its URL starting
+// with a slash. Either may be empty if the endpoint doesn't have it.
+func methodAndPath(endpoint map[string]any) (string, string) {
+	request, ok := endpoint["request"].(map[string]any)
+	if !ok {
+		return "", ""
+	}
+	method, _ := request["method"].(string)
+	switch url := request["url"].(type) {
+	case map[string]any:
+		if pathElems, ok := url["path"].([]any); ok {
+			strElems := make([]string, len(pathElems))
+			for i, e := range pathElems {
+				strElems[i] = fmt.Sprint(e)
+			}
+			return method, "/" + strings.Join(strElems, "/")
+		}
+	case string:
+		return method, url
+	}
+	return method, ""
+}
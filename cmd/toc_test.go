@@ -0,0 +1,244 @@
+// Copyright 2023 Chris Wheeler
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+// tocSummary converts each entry of a collection's table of contents to a string
+// containing the entry's indent, number, name, link, method, and path.
+func tocSummary(collection map[string]any) []string {
+	toc := collection["toc"].([]any)
+	summary := make([]string, len(toc))
+	for i, entryAny := range toc {
+		entry := entryAny.(map[string]any)
+		summary[i] = fmt.Sprintf(
+			"%s%s %s %s %s %s",
+			entry["indent"], entry["number"], entry["name"], entry["link"], entry["method"], entry["path"],
+		)
+	}
+	return summary
+}
+
+func TestAddTableOfContents(t *testing.T) {
+	tests := []struct {
+		name string
+		opts tocOptions
+		want []string
+	}{
+		{
+			"all levels",
+			tocOptions{},
+			[]string{
+				" POST endpoints #post-endpoints  ",
+				"   create account #create-account  ",
+				"   log in #log-in  ",
+				" empty folder #empty-folder  ",
+				" GET endpoints #get-endpoints  ",
+				"   get all accounts #get-all-accounts  ",
+				" edit account #edit-account  ",
+				" delete account #delete-account  ",
+			},
+		},
+		{
+			"max depth with numbers and methods",
+			tocOptions{maxDepth: 1, numbered: true, showMethods: true},
+			[]string{
+				"1 POST endpoints #post-endpoints  ",
+				"2 empty folder #empty-folder  ",
+				"3 GET endpoints #get-endpoints  ",
+				"4 edit account #edit-account PUT /v1/account",
+				"5 delete account #delete-account DELETE /v1/account",
+			},
+		},
+		{
+			"nested numbers",
+			tocOptions{maxDepth: 2, numbered: true},
+			[]string{
+				"1 POST endpoints #post-endpoints  ",
+				"  1.1 create account #create-account  ",
+				"  1.2 log in #log-in  ",
+				"2 empty folder #empty-folder  ",
+				"3 GET endpoints #get-endpoints  ",
+				"  3.1 get all accounts #get-all-accounts  ",
+				"4 edit account #edit-account  ",
+				"5 delete account #delete-account  ",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collection, err := getCollection(t, "../samples/calendar-API.postman_collection.json")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			addTableOfContents(collection, anchorStyles[defaultAnchorStyle], test.opts, headingStrategy{})
+			ans := tocSummary(collection)
+			if len(ans) != len(test.want) {
+				t.Errorf("got %d entries, want %d: %q", len(ans), len(test.want), ans)
+				return
+			}
+			for i := range ans {
+				if ans[i] != test.want[i] {
+					t.Errorf("entry %d = %q, want %q", i, ans[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAddTableOfContentsDuplicateNames(t *testing.T) {
+	collection := map[string]any{
+		"info": map[string]any{"name": "users"},
+		"item": []any{
+			map[string]any{
+				"name": "users",
+				"item": []any{
+					map[string]any{"name": "get", "request": map[string]any{}},
+				},
+			},
+			map[string]any{"name": "get", "request": map[string]any{}},
+		},
+	}
+	want := []string{
+		" users #users-1  ",
+		" get #get-1  ",
+	}
+
+	addTableOfContents(collection, anchorStyles[defaultAnchorStyle], tocOptions{maxDepth: 1}, headingStrategy{})
+	ans := tocSummary(collection)
+	if len(ans) != len(want) {
+		t.Errorf("got %d entries, want %d: %q", len(ans), len(want), ans)
+		return
+	}
+	for i := range ans {
+		if ans[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, ans[i], want[i])
+		}
+	}
+}
+
+func TestAddTableOfContentsDuplicateSampleHeaders(t *testing.T) {
+	collection := map[string]any{
+		"info": map[string]any{"name": "api"},
+		"item": []any{
+			map[string]any{
+				"name": "create",
+				"request": map[string]any{
+					"method": "POST",
+					"url":    map[string]any{"path": []any{"users"}},
+					"body":   map[string]any{"mode": "raw", "raw": "{}"},
+				},
+				"response": []any{
+					map[string]any{"name": "", "code": float64(200), "status": "OK", "body": ""},
+				},
+			},
+			map[string]any{"name": "sample request body", "request": map[string]any{}, "response": []any{}},
+			map[string]any{"name": "sample response (status: 200 OK)", "request": map[string]any{}, "response": []any{}},
+		},
+	}
+	want := []string{
+		" create #create  ",
+		" sample request body #sample-request-body-1  ",
+		" sample response (status: 200 OK) #sample-response-status-200-ok-1  ",
+	}
+
+	addTableOfContents(collection, anchorStyles[defaultAnchorStyle], tocOptions{}, headingStrategy{})
+	ans := tocSummary(collection)
+	if len(ans) != len(want) {
+		t.Errorf("got %d entries, want %d: %q", len(ans), len(want), ans)
+		return
+	}
+	for i := range ans {
+		if ans[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, ans[i], want[i])
+		}
+	}
+}
+
+func TestAddTableOfContentsBoldHeaders(t *testing.T) {
+	collection := map[string]any{
+		"info": map[string]any{"name": "api"},
+		"item": []any{
+			map[string]any{
+				"name": "users",
+				"item": []any{
+					map[string]any{
+						"name": "admin",
+						"item": []any{
+							map[string]any{"name": "get", "request": map[string]any{}, "response": []any{}},
+						},
+					},
+				},
+			},
+			map[string]any{"name": "get", "request": map[string]any{}, "response": []any{}},
+		},
+	}
+	want := []string{
+		" users #users  ",
+		"   admin #admin  ",
+		"     get   ",
+		" get #get  ",
+	}
+
+	headings := headingStrategy{base: 5, overflow: "bold"}
+	addTableOfContents(collection, anchorStyles[defaultAnchorStyle], tocOptions{}, headings)
+	ans := tocSummary(collection)
+	if len(ans) != len(want) {
+		t.Errorf("got %d entries, want %d: %q", len(ans), len(want), ans)
+		return
+	}
+	for i := range ans {
+		if ans[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, ans[i], want[i])
+		}
+	}
+}
+
+func TestMethodAndPath(t *testing.T) {
+	tests := []struct {
+		name                 string
+		endpoint             map[string]any
+		wantMethod, wantPath string
+	}{
+		{
+			"parsed URL",
+			map[string]any{"request": map[string]any{
+				"method": "GET",
+				"url":    map[string]any{"path": []any{"v1", "events"}},
+			}},
+			"GET", "/v1/events",
+		},
+		{
+			"string URL",
+			map[string]any{"request": map[string]any{"method": "POST", "url": "/v1/events"}},
+			"POST", "/v1/events",
+		},
+		{"no request", map[string]any{}, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method, path := methodAndPath(test.endpoint)
+			if method != test.wantMethod || path != test.wantPath {
+				t.Errorf("methodAndPath(...) = (%q, %q), want (%q, %q)", method, path, test.wantMethod, test.wantPath)
+			}
+		})
+	}
+}
@@ -15,7 +15,6 @@
 package cmd
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"io"
@@ -70,18 +69,24 @@ func FormatFileName(fileName string) string {
 	return strings.Trim(string(result), invalidEdgeChars)
 }
 
-// ScanStdin reads input from stdin until it finds EOF or a different error, and then
-// returns any input all at once. If EOF is found, the returned error is nil.
-func ScanStdin() ([]byte, error) {
-	lines := make([]string, 0)
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// openInput opens the file at the given path, or stdin if the path is "-". Closing the
+// returned stdin does nothing.
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("stdin scan error: %s", err)
+	return os.Open(path)
+}
+
+// ReadInput reads all of the file at the given path, or all of stdin if the path is "-".
+// The bytes are returned exactly as they are.
+func ReadInput(path string) ([]byte, error) {
+	input, err := openInput(path)
+	if err != nil {
+		return nil, err
 	}
-	return []byte(strings.Join(lines, "\n")), nil
+	defer input.Close()
+	return io.ReadAll(input)
 }
 
 // exportText creates a new file with a unique name based on the given base name (no
@@ -105,52 +110,30 @@ func exportText(baseName, ext, content string) string {
 	return uniqueName
 }
 
-// AssertGenerateNoDiff converts JSON to plaintext and asserts the result is the same as
-// wanted text. wantPath is the path to an existing file containing the wanted output.
-// If the given template path is empty, the default template is used. If any status
-// ranges are given, responses with statuses outside those ranges will not be present in
-// the result.
-func AssertGenerateNoDiff(jsonPath, tmplPath, wantPath string, statusRanges [][]int) error {
-	jsonBytes, err := os.ReadFile(jsonPath)
+// AssertGenerateNoDiff converts a target's input to plaintext with the target's options
+// and asserts the result is the same as wanted text. wantPath is the path to an existing
+// file containing the wanted output. The input is converted the same way as by
+// generateTarget, but the result isn't saved.
+func AssertGenerateNoDiff(target Target, wantPath string) error {
+	opts, err := target.renderOptions()
 	if err != nil {
 		return err
 	}
-	openAnsFile, err := os.CreateTemp("", "pm2md_*.md")
+	collection, err := loadCollection(target)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(openAnsFile.Name())
-	defer openAnsFile.Close()
 	wantBytes, err := os.ReadFile(wantPath)
 	if err != nil {
 		return err
 	}
 
-	collection, err := parseCollection(jsonBytes)
-	if err != nil {
-		return err
-	}
-
-	err = generateText(
-		collection,
-		openAnsFile,
-		tmplPath,
-		statusRanges,
-	)
-	if err != nil {
-		return err
-	}
-	fileInfo, err := openAnsFile.Stat()
-	if err != nil {
-		return err
-	}
-	ansBytes := make([]byte, fileInfo.Size())
-	_, err = openAnsFile.Read(ansBytes)
-	if err != nil && err != io.EOF {
+	var b strings.Builder
+	if err := renderText(collection, &b, opts); err != nil {
 		return err
 	}
 
-	ans := strings.ReplaceAll(string(ansBytes), "\r\n", "\n")
+	ans := strings.ReplaceAll(b.String(), "\r\n", "\n")
 	want := strings.ReplaceAll(string(wantBytes), "\r\n", "\n")
 
 	return AssertNoDiff(ans, want, "\n")
@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -137,3 +138,41 @@ func TestExportText(t *testing.T) {
 		return
 	}
 }
+
+func TestAssertGenerateNoDiffWithRenderOptions(t *testing.T) {
+	dir := t.TempDir()
+	partialsDir := filepath.Join(dir, "partials")
+	if err := os.Mkdir(partialsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	partial := `{{define "method"}}{{.request.method}}{{end}}`
+	if err := os.WriteFile(filepath.Join(partialsDir, "method.tmpl"), []byte(partial), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	override := `{{define "request"}}` + "\n\n" + `method: {{template "method" .}}{{end}}`
+	overridePath := filepath.Join(dir, "request.tmpl")
+	if err := os.WriteFile(overridePath, []byte(override), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := Target{
+		Input:        "../samples/calendar-API.postman_collection.json",
+		Output:       filepath.Join(dir, "expected.md"),
+		Template:     TemplateList{"@default", overridePath},
+		TemplatePath: []string{partialsDir},
+		Anchors:      "gitlab",
+		TOC:          TOCConfig{Numbers: true},
+		Sort:         SortConfig{Items: "name"},
+	}
+	if _, err := generateTarget(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := AssertGenerateNoDiff(target, target.Output); err != nil {
+		t.Errorf("AssertGenerateNoDiff with the options that generated the file: %s", err)
+	}
+
+	target.TOC.Numbers = false
+	if err := AssertGenerateNoDiff(target, target.Output); err == nil {
+		t.Error("AssertGenerateNoDiff with different options returned nil error, want non-nil error")
+	}
+}